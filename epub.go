@@ -0,0 +1,657 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/shravanasati/ananke/html2md"
+)
+
+// xmlNode is an element or, when name is empty, a text node of an XML
+// document of an EPUB.
+type xmlNode struct {
+	name     string            // local name of the element
+	attrs    map[string]string // by local name
+	children []*xmlNode
+	text     string
+}
+
+// parseXML parses an XML document, leniently so that the XHTML documents with
+// HTML entities are read too.
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	root := &xmlNode{}
+	open := []*xmlNode{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := open[len(open)-1]
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: token.Name.Local, attrs: map[string]string{}}
+			for _, attr := range token.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			parent.children = append(parent.children, node)
+			open = append(open, node)
+		case xml.EndElement:
+			if len(open) > 1 {
+				open = open[:len(open)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &xmlNode{text: string(token)})
+		}
+	}
+}
+
+// find returns the first descendant element with the name, or nil.
+func (n *xmlNode) find(name string) *xmlNode {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+		if found := child.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// elements returns the child elements with the name.
+func (n *xmlNode) elements(name string) []*xmlNode {
+	var elements []*xmlNode
+	for _, child := range n.children {
+		if child.name == name {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// textContent returns the text of the node and its descendants, with the
+// whitespace collapsed.
+func (n *xmlNode) textContent() string {
+	var builder strings.Builder
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		builder.WriteString(n.text)
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// voidElements are the HTML elements without content, written without an end
+// tag.
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr"}
+
+// html returns the node as HTML. The self-closing elements of XHTML, like
+// `<title/>`, are read as open by HTML parsers, they get an end tag. The SVG
+// images, like the cover of the book, are written as `<img>`.
+func (n *xmlNode) html() string {
+	var builder strings.Builder
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		builder.WriteString(html.EscapeString(n.text))
+		if n.name == "svg" {
+			for _, image := range n.svgImages() {
+				builder.WriteString(`<img src="` + html.EscapeString(image) + `">`)
+			}
+			return
+		}
+		if n.name != "" {
+			builder.WriteString("<" + n.name)
+			for _, key := range slices.Sorted(maps.Keys(n.attrs)) {
+				builder.WriteString(" " + key + `="` + html.EscapeString(n.attrs[key]) + `"`)
+			}
+			builder.WriteString(">")
+			if slices.Contains(voidElements, n.name) {
+				return
+			}
+			// HTML parsers drop the newline right after the start of a `<pre>`
+			if n.name == "pre" && len(n.children) > 0 && strings.HasPrefix(n.children[0].text, "\n") {
+				builder.WriteString("\n")
+			}
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+		if n.name != "" {
+			builder.WriteString("</" + n.name + ">")
+		}
+	}
+	walk(n)
+	return builder.String()
+}
+
+// svgImages returns the href of the `<image>` elements of an SVG, which is
+// `xlink:href` in SVG 1.1.
+func (n *xmlNode) svgImages() []string {
+	var images []string
+	for _, child := range n.children {
+		if child.name == "image" && child.attrs["href"] != "" {
+			images = append(images, child.attrs["href"])
+		}
+		images = append(images, child.svgImages()...)
+	}
+	return images
+}
+
+// tocEntry is an entry of the table of contents of a book, its href is the
+// path in the archive followed by the fragment.
+type tocEntry struct {
+	title    string
+	href     string
+	children []tocEntry
+}
+
+// epubBook is an EPUB archive read from its package document.
+type epubBook struct {
+	title string
+	files map[string]*zip.File // by path in the archive
+	dir   string               // directory of the package document
+	// the paths of the documents of the spine in reading order, and the
+	// name of their markdown file
+	spine []string
+	names map[string]string
+	toc   []tocEntry
+}
+
+// readFile returns the content of a file of the archive.
+func (b *epubBook) readFile(name string) ([]byte, error) {
+	file, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing from the book", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// readXML parses an XML file of the archive.
+func (b *epubBook) readXML(name string) (*xmlNode, error) {
+	data, err := b.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	node, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return node, nil
+}
+
+// resolve returns the path in the archive of a link of the document at base,
+// and its fragment. It reports false for the links to other sites.
+func resolve(base, href string) (target, fragment string, ok bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(u.Path, "/") {
+		return "", "", false
+	}
+	if u.Path == "" {
+		return base, u.Fragment, true
+	}
+	return path.Join(path.Dir(base), u.Path), u.Fragment, true
+}
+
+// escapePath escapes the segments of a path for a markdown link.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// readEPUB reads the package document of the book, named by the container
+// file, and its table of contents.
+func readEPUB(archive *zip.Reader) (*epubBook, error) {
+	book := &epubBook{files: map[string]*zip.File{}, names: map[string]string{}}
+	for _, file := range archive.File {
+		book.files[file.Name] = file
+	}
+
+	container, err := book.readXML("META-INF/container.xml")
+	if err != nil {
+		return nil, err
+	}
+	rootfile := container.find("rootfile")
+	if rootfile == nil || rootfile.attrs["full-path"] == "" {
+		return nil, errors.New("the container of the book names no package document")
+	}
+	opfPath := rootfile.attrs["full-path"]
+	pkg, err := book.readXML(opfPath)
+	if err != nil {
+		return nil, err
+	}
+	book.dir = path.Dir(opfPath)
+	if title := pkg.find("title"); title != nil {
+		book.title = title.textContent()
+	}
+
+	// the manifest lists the files of the book by id
+	type item struct{ path, mediaType string }
+	manifest := map[string]item{}
+	var nav string
+	if node := pkg.find("manifest"); node != nil {
+		for _, entry := range node.elements("item") {
+			target, _, ok := resolve(opfPath, entry.attrs["href"])
+			if !ok {
+				continue
+			}
+			manifest[entry.attrs["id"]] = item{target, entry.attrs["media-type"]}
+			if slices.Contains(strings.Fields(entry.attrs["properties"]), "nav") {
+				nav = target
+			}
+		}
+	}
+
+	spine := pkg.find("spine")
+	if spine == nil {
+		return nil, errors.New("the package document of the book has no spine")
+	}
+	// the index of the book is written next to the chapters
+	used := map[string]bool{"index.md": true}
+	for _, ref := range spine.elements("itemref") {
+		entry, ok := manifest[ref.attrs["idref"]]
+		if !ok || entry.mediaType != "application/xhtml+xml" && entry.mediaType != "text/html" {
+			continue
+		}
+		// the chapters are written next to each other, named after their file
+		name := strings.TrimSuffix(path.Base(entry.path), path.Ext(entry.path))
+		unique := name + ".md"
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d.md", name, i)
+		}
+		used[unique] = true
+		book.spine = append(book.spine, entry.path)
+		book.names[entry.path] = unique
+	}
+
+	// the navigation document of EPUB 3 replaces the NCX of EPUB 2
+	switch ncx, hasNCX := manifest[spine.attrs["toc"]]; {
+	case nav != "":
+		document, err := book.readXML(nav)
+		if err != nil {
+			return nil, err
+		}
+		book.toc = navEntries(document, nav)
+	case hasNCX:
+		document, err := book.readXML(ncx.path)
+		if err != nil {
+			return nil, err
+		}
+		if navMap := document.find("navMap"); navMap != nil {
+			book.toc = ncxEntries(navMap, ncx.path)
+		}
+	}
+	return book, nil
+}
+
+// tocHref returns the href of a table of contents entry linking to href from
+// the document at base.
+func tocHref(base, href string) string {
+	target, fragment, ok := resolve(base, href)
+	if !ok {
+		return href
+	}
+	if fragment != "" {
+		return escapePath(target) + "#" + fragment
+	}
+	return escapePath(target)
+}
+
+// navEntries returns the entries of the `<nav epub:type="toc">` of the
+// navigation document, or of its first `<nav>`.
+func navEntries(document *xmlNode, base string) []tocEntry {
+	var navs []*xmlNode
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		for _, child := range n.children {
+			if child.name == "nav" {
+				navs = append(navs, child)
+			}
+			walk(child)
+		}
+	}
+	walk(document)
+	if len(navs) == 0 {
+		return nil
+	}
+	toc := navs[0]
+	for _, nav := range navs {
+		if slices.Contains(strings.Fields(nav.attrs["type"]), "toc") {
+			toc = nav
+			break
+		}
+	}
+
+	var entries func(list *xmlNode) []tocEntry
+	entries = func(list *xmlNode) []tocEntry {
+		var result []tocEntry
+		for _, item := range list.elements("li") {
+			var entry tocEntry
+			for _, child := range item.children {
+				switch child.name {
+				case "a":
+					entry.title, entry.href = child.textContent(), tocHref(base, child.attrs["href"])
+				case "span":
+					entry.title = child.textContent()
+				case "ol", "ul":
+					entry.children = entries(child)
+				}
+			}
+			result = append(result, entry)
+		}
+		return result
+	}
+	if list := toc.find("ol"); list != nil {
+		return entries(list)
+	}
+	return nil
+}
+
+// ncxEntries returns the entries of the navigation points of the NCX.
+func ncxEntries(parent *xmlNode, base string) []tocEntry {
+	var result []tocEntry
+	for _, point := range parent.elements("navPoint") {
+		entry := tocEntry{children: ncxEntries(point, base)}
+		if label := point.find("navLabel"); label != nil {
+			entry.title = label.textContent()
+		}
+		if content := point.find("content"); content != nil {
+			entry.href = tocHref(base, content.attrs["src"])
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// chapterAnchor returns the id of the anchor starting a chapter in a single
+// markdown file.
+func chapterAnchor(name string) string {
+	return strings.TrimSuffix(name, ".md")
+}
+
+// fragmentAnchor returns the id of an element of a chapter in a single markdown
+// file, prefixed by the anchor of its chapter so that the ids of the chapters
+// don't collide.
+func fragmentAnchor(name, fragment string) string {
+	return chapterAnchor(name) + "-" + fragment
+}
+
+// linkTargets returns the elements linked to by the chapters and the table of
+// contents, as their path in the archive followed by their fragment.
+func (b *epubBook) linkTargets(documents map[string]*xmlNode) map[string]bool {
+	targets := map[string]bool{}
+	add := func(base, href string) {
+		if target, fragment, ok := resolve(base, href); ok && fragment != "" {
+			targets[target+"#"+fragment] = true
+		}
+	}
+	var walk func(base string, n *xmlNode)
+	walk = func(base string, n *xmlNode) {
+		if n.name == "a" && n.attrs["href"] != "" {
+			add(base, n.attrs["href"])
+		}
+		for _, child := range n.children {
+			walk(base, child)
+		}
+	}
+	for chapter, document := range documents {
+		walk(chapter, document)
+	}
+	var walkTOC func(entries []tocEntry)
+	walkTOC = func(entries []tocEntry) {
+		for _, entry := range entries {
+			add("", entry.href)
+			walkTOC(entry.children)
+		}
+	}
+	walkTOC(b.toc)
+	return targets
+}
+
+// markTargets keeps the ids of the elements of the chapter linked to, and
+// removes the others. The headings and the anchors keep their id, which the
+// converter writes, the other elements get an anchor holding it. In a single
+// file the ids are prefixed by the anchor of the chapter.
+func (b *epubBook) markTargets(chapter string, document *xmlNode, targets map[string]bool, single bool) {
+	var walk func(n *xmlNode)
+	walk = func(n *xmlNode) {
+		var children []*xmlNode
+		for _, child := range n.children {
+			walk(child)
+			id, hasID := child.attrs["id"]
+			if !hasID {
+				children = append(children, child)
+				continue
+			}
+			delete(child.attrs, "id")
+			if !targets[chapter+"#"+id] {
+				children = append(children, child)
+				continue
+			}
+			if single {
+				id = fragmentAnchor(b.names[chapter], id)
+			}
+			anchor := &xmlNode{name: "a", attrs: map[string]string{"id": id}}
+			switch {
+			case isHeading(child.name), child.name == "a" && child.attrs["href"] == "":
+				child.attrs["id"] = id
+				children = append(children, child)
+			case child.name == "a", slices.Contains(voidElements, child.name):
+				// the anchor can't be inside a link or an element without content
+				children = append(children, anchor, child)
+			default:
+				child.children = append([]*xmlNode{anchor}, child.children...)
+				children = append(children, child)
+			}
+		}
+		n.children = children
+	}
+	walk(document)
+}
+
+// isHeading reports whether the element is a heading, from `<h1>` to `<h6>`.
+func isHeading(name string) bool {
+	return len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+}
+
+// rewriter returns the URL rewriter of the document at base: the links to the
+// chapters point to their markdown file, or to their anchor in a single file,
+// and the files of the book, like images, are extracted to the assets
+// directory.
+func (b *epubBook) rewriter(base string, single bool, assets map[string]bool) func(string) string {
+	return func(href string) string {
+		target, fragment, ok := resolve(base, href)
+		if !ok {
+			return href
+		}
+		if name, isChapter := b.names[target]; isChapter {
+			switch {
+			case fragment != "" && single:
+				return "#" + fragmentAnchor(name, fragment)
+			case fragment != "" && target == base:
+				return "#" + fragment
+			case single:
+				return "#" + chapterAnchor(name)
+			case fragment != "":
+				return escapePath(name) + "#" + fragment
+			default:
+				return escapePath(name)
+			}
+		}
+		if _, exists := b.files[target]; !exists || !filepath.IsLocal(filepath.FromSlash(b.assetPath(target))) {
+			return href
+		}
+		assets[target] = true
+		return "assets/" + escapePath(b.assetPath(target))
+	}
+}
+
+// assetPath returns the path of a file of the book in the assets directory,
+// relative to the package document.
+func (b *epubBook) assetPath(target string) string {
+	if b.dir == "." {
+		return target
+	}
+	if rel, ok := strings.CutPrefix(target, b.dir+"/"); ok {
+		return rel
+	}
+	return target
+}
+
+// index returns the HTML of the title and the table of contents of the book,
+// whose entries are documents of the spine when it has none.
+func (b *epubBook) index() string {
+	entries := b.toc
+	if len(entries) == 0 {
+		for _, chapter := range b.spine {
+			entries = append(entries, tocEntry{title: chapterAnchor(b.names[chapter]), href: escapePath(chapter)})
+		}
+	}
+
+	var builder strings.Builder
+	var writeList func(entries []tocEntry)
+	writeList = func(entries []tocEntry) {
+		builder.WriteString("<ul>")
+		for _, entry := range entries {
+			builder.WriteString("<li>")
+			if entry.href != "" {
+				builder.WriteString(`<a href="` + html.EscapeString(entry.href) + `">` + html.EscapeString(entry.title) + "</a>")
+			} else {
+				builder.WriteString(html.EscapeString(entry.title))
+			}
+			if len(entry.children) > 0 {
+				writeList(entry.children)
+			}
+			builder.WriteString("</li>")
+		}
+		builder.WriteString("</ul>")
+	}
+	if b.title != "" {
+		builder.WriteString("<h1>" + html.EscapeString(b.title) + "</h1>")
+	}
+	writeList(entries)
+	return builder.String()
+}
+
+// convertEPUB converts the chapters of the book at input to markdown files in
+// outdir, in the order of the spine, with an index.md file holding its table
+// of contents. The images are extracted to outdir/assets. When single is set,
+// the table of contents and the chapters are written to one markdown file
+// named after the book.
+func convertEPUB(input, outdir string, single bool) error {
+	archive, err := zip.OpenReader(input)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	book, err := readEPUB(&archive.Reader)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return err
+	}
+
+	documents := map[string]*xmlNode{}
+	for _, chapter := range book.spine {
+		if documents[chapter], err = book.readXML(chapter); err != nil {
+			return err
+		}
+	}
+	targets := book.linkTargets(documents)
+
+	assets := map[string]bool{}
+	convert := func(base, document string) (string, error) {
+		converter := html2md.NewConverter(
+			html2md.WithURLRewriter(book.rewriter(base, single, assets)),
+			// the ids of the headings and the anchors are the targets of links
+			html2md.WithHeadingIDs(html2md.HeadingIDAnchor),
+		)
+		output, err := converter.ConvertString(document)
+		// the whitespace around the body of a chapter is not content
+		return strings.TrimLeft(output, "\n"), err
+	}
+
+	// the paths of the table of contents are in the archive
+	index, err := convert("", book.index())
+	if err != nil {
+		return err
+	}
+	var combined strings.Builder
+	if single {
+		combined.WriteString(index)
+	} else if err := os.WriteFile(filepath.Join(outdir, "index.md"), []byte(index), 0o644); err != nil {
+		return err
+	}
+
+	for _, chapter := range book.spine {
+		book.markTargets(chapter, documents[chapter], targets, single)
+		// the head of a chapter, like its title, is not content
+		content := documents[chapter]
+		if body := content.find("body"); body != nil {
+			content = body
+		}
+		output, err := convert(chapter, content.html())
+		if err != nil {
+			return fmt.Errorf("%s: %w", chapter, err)
+		}
+		if single {
+			fmt.Fprintf(&combined, "<a id=\"%s\"></a>\n\n%s", chapterAnchor(book.names[chapter]), output)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(outdir, book.names[chapter]), []byte(output), 0o644); err != nil {
+			return err
+		}
+	}
+	if single {
+		name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + ".md"
+		if err := os.WriteFile(filepath.Join(outdir, name), []byte(combined.String()), 0o644); err != nil {
+			return err
+		}
+	}
+
+	for asset := range assets {
+		data, err := book.readFile(asset)
+		if err != nil {
+			return err
+		}
+		destination := filepath.Join(outdir, "assets", filepath.FromSlash(book.assetPath(asset)))
+		if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(destination, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	containerXML = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+	packageXML = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>The Test Book</dc:title></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="c1" href="Text/chapter1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="Text/chapter2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="Text/notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="cover" href="Text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="fig" href="Images/figure%201.png" media-type="image/png"/>
+    <item id="cover-image" href="Images/cover.jpg" media-type="image/jpeg"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine toc="ncx"><itemref idref="cover"/><itemref idref="c2"/><itemref idref="c1"/><itemref idref="notes"/></spine>
+</package>`
+
+	navXHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="landmarks"><ol><li><a href="Text/chapter2.xhtml">Start</a></li></ol></nav>
+<nav epub:type="toc"><h1>Contents</h1><ol>
+  <li><a href="Text/chapter2.xhtml">Opening</a></li>
+  <li><a href="Text/chapter1.xhtml"><span>Second</span> Chapter</a>
+    <ol><li><a href="Text/chapter1.xhtml#details">Details</a></li></ol></li>
+</ol></nav></body></html>`
+
+	ncxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>
+  <navPoint id="p1"><navLabel><text>Opening (NCX)</text></navLabel><content src="Text/chapter2.xhtml"/></navPoint>
+  <navPoint id="p2"><navLabel><text>Second (NCX)</text></navLabel><content src="Text/chapter1.xhtml"/>
+    <navPoint id="p3"><navLabel><text>Details</text></navLabel><content src="Text/chapter1.xhtml#details"/></navPoint>
+  </navPoint>
+</navMap></ncx>`
+
+	chapter1XHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Chapter 1</title><link rel="stylesheet" href="../style.css"/></head>
+<body><h1>Second Chapter</h1><p>Back to the <a href="chapter2.xhtml">opening</a>&#160;or <a href="#details">below</a>.</p>
+<h2 id="details">Details</h2><p><img src="../Images/figure%201.png" alt="Figure"/> See <a href="https://example.com">the site</a>.</p>
+<p id="claim">A claim.<a id="ref12" href="notes.xhtml#n12">12</a></p></body></html>`
+
+	chapter2XHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title/><meta charset="utf-8"/></head>
+<body><h1 id="opening">Opening</h1><p>Read the <a href="chapter1.xhtml#details">details</a>.</p></body></html>`
+
+	coverXHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:xlink="http://www.w3.org/1999/xlink"><head><title>Cover</title></head>
+<body><div><svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 600 800"><image width="600" height="800" xlink:href="../Images/cover.jpg"/></svg></div></body></html>`
+
+	notesXHTML = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Notes</title></head>
+<body><h1>Notes</h1><aside id="n12"><p><a href="chapter1.xhtml#ref12">12</a>. See the <span id="unlinked">note</span>.</p></aside></body></html>`
+)
+
+// writeEPUB writes a book with the files to a temporary directory.
+func writeEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "book.epub")
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	// the mimetype comes first, uncompressed
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write([]byte("application/epub+zip"))
+	for path, content := range files {
+		writer, err := archive.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func bookFiles() map[string]string {
+	return map[string]string{
+		"META-INF/container.xml":    containerXML,
+		"OEBPS/content.opf":         packageXML,
+		"OEBPS/nav.xhtml":           navXHTML,
+		"OEBPS/toc.ncx":             ncxXML,
+		"OEBPS/Text/chapter1.xhtml": chapter1XHTML,
+		"OEBPS/Text/chapter2.xhtml": chapter2XHTML,
+		"OEBPS/Text/notes.xhtml":    notesXHTML,
+		"OEBPS/Text/cover.xhtml":    coverXHTML,
+		"OEBPS/Images/figure 1.png": "png",
+		"OEBPS/Images/cover.jpg":    "jpg",
+		"OEBPS/style.css":           "p {}",
+	}
+}
+
+// readOutput returns the markdown files written to the directory.
+func readOutput(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	output := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		rel, _ := filepath.Rel(dir, path)
+		output[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestConvertEPUB(t *testing.T) {
+	outdir := filepath.Join(t.TempDir(), "out")
+	if err := convertEPUB(writeEPUB(t, bookFiles()), outdir, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"index.md":                   "# The Test Book\n- [Opening](chapter2.md)\n- [Second Chapter](chapter1.md)\n\t- [Details](chapter1.md#details)\n\n",
+		"chapter2.md":                "# Opening\nRead the [details](chapter1.md#details).\n\n",
+		"chapter1.md":                "# Second Chapter\nBack to the [opening](chapter2.md)\u00a0or [below](#details).\n\n## <a id=\"details\"></a>Details\n![Figure](assets/Images/figure%201.png)\nSee [the site](https://example.com).\n\nA claim.<a id=\"ref12\"></a>[12](notes.md#n12)\n\n",
+		"notes.md":                   "# Notes\n<a id=\"n12\"></a>[12](chapter1.md#ref12). See the note.\n\n",
+		"cover.md":                   "![cover](assets/Images/cover.jpg)\n",
+		"assets/Images/figure 1.png": "png",
+		"assets/Images/cover.jpg":    "jpg",
+	}
+	output := readOutput(t, outdir)
+	if len(output) != len(expected) {
+		t.Errorf("unexpected files: %v", keys(output))
+	}
+	for name, content := range expected {
+		if output[name] != content {
+			t.Errorf("unexpected %s:\nGot:      %q\nExpected: %q", name, output[name], content)
+		}
+	}
+}
+
+func TestConvertEPUBSingleFile(t *testing.T) {
+	outdir := t.TempDir()
+	if err := convertEPUB(writeEPUB(t, bookFiles()), outdir, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := readOutput(t, outdir)
+	book := output["book.md"]
+	for _, part := range []string{
+		"# The Test Book\n- [Opening](#chapter2)\n- [Second Chapter](#chapter1)\n\t- [Details](#chapter1-details)\n",
+		"<a id=\"chapter2\"></a>\n\n# Opening\nRead the [details](#chapter1-details).",
+		"<a id=\"chapter1\"></a>\n\n# Second Chapter\nBack to the [opening](#chapter2)\u00a0or [below](#chapter1-details).",
+		"## <a id=\"chapter1-details\"></a>Details\n",
+		"A claim.<a id=\"chapter1-ref12\"></a>[12](#notes-n12)",
+		"<a id=\"notes-n12\"></a>[12](#chapter1-ref12). See the note.",
+		"![Figure](assets/Images/figure%201.png)",
+		"<a id=\"cover\"></a>\n\n![cover](assets/Images/cover.jpg)\n",
+	} {
+		if !strings.Contains(book, part) {
+			t.Errorf("missing %q in:\n%s", part, book)
+		}
+	}
+	if strings.Index(book, "# Opening") > strings.Index(book, "# Second Chapter") {
+		t.Errorf("chapters not in the order of the spine:\n%s", book)
+	}
+	if _, ok := output["assets/Images/cover.jpg"]; !ok || len(output) != 3 {
+		t.Errorf("unexpected files: %v", keys(output))
+	}
+}
+
+func TestConvertEPUBWithNCX(t *testing.T) {
+	files := bookFiles()
+	delete(files, "OEBPS/nav.xhtml")
+	files["OEBPS/content.opf"] = strings.Replace(packageXML, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`, "", 1)
+	outdir := t.TempDir()
+	if err := convertEPUB(writeEPUB(t, files), outdir, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# The Test Book\n- [Opening (NCX)](chapter2.md)\n- [Second (NCX)](chapter1.md)\n\t- [Details](chapter1.md#details)\n\n"
+	if index := readOutput(t, outdir)["index.md"]; index != expected {
+		t.Errorf("unexpected index:\nGot:      %q\nExpected: %q", index, expected)
+	}
+}
+
+func TestParseEPUBArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected epubArgs
+		ok       bool
+		err      bool
+	}{
+		{args: []string{"book.epub"}, expected: epubArgs{input: "book.epub", outdir: "book"}, ok: true},
+		{args: []string{"-single", "dir/book.EPUB", "-o", "out"}, expected: epubArgs{input: "dir/book.EPUB", outdir: "out", single: true}, ok: true},
+		{args: []string{"book.epub", "other.epub"}, ok: true, err: true},
+		{args: []string{"book.epub", "-x"}, ok: true, err: true},
+		{args: []string{"get", "the", "book.epub"}},
+		{args: []string{"<p>-5", "degrees</p>"}},
+		{args: []string{"-o"}},
+		{args: nil},
+	}
+
+	for _, test := range tests {
+		parsed, ok, err := parseEPUBArgs(test.args)
+		if ok != test.ok || (err != nil) != test.err {
+			t.Errorf("%q: unexpected result %v, error %v", test.args, ok, err)
+			continue
+		}
+		if ok && !test.err && parsed != test.expected {
+			t.Errorf("%q: unexpected arguments %+v, expected %+v", test.args, parsed, test.expected)
+		}
+	}
+}
+
+func keys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,254 @@
+package html2md
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// characters removed from the heading text by GitHub to create its slug.
+var slugRemoveRegex = regexp.MustCompile(`[^\p{L}\p{M}\p{N}\p{Pc}\- ]`)
+
+// githubSlug returns the id GitHub generates for a heading with the given text,
+// before deduplication.
+func githubSlug(text string) string {
+	text = strings.ToLower(strings.TrimSpace(collapseWhitespace(text)))
+	return strings.ReplaceAll(slugRemoveRegex.ReplaceAllString(text, ""), " ", "-")
+}
+
+// classes of the permalink anchors added to headings by documentation
+// generators, like Sphinx's `headerlink` or Docusaurus' `hash-link`.
+var permalinkClasses = []string{
+	"headerlink", "anchor", "anchor-link", "anchorjs-link", "hash-link", "header-anchor",
+	"heading-anchor", "headeranchor-link", "permalink", "zola-anchor", "direct-link",
+}
+
+var skipLinkRegex = regexp.MustCompile(`(?i)^skip\b.*\b(content|navigation|nav|main|menu)\b`)
+
+// nearHeading reports whether the node is inside a heading, or next to one.
+func nearHeading(node *html.Node) bool {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if headingLevel(n) > 0 {
+			return true
+		}
+	}
+	if node.Parent == nil {
+		return false
+	}
+	for sibling := range node.Parent.ChildNodes() {
+		if headingLevel(sibling) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isDecorativeLink reports whether the node is a link without content of its
+// own: a permalink anchor of a heading, a skip-to-content link, or an anchor
+// without any text or image which doesn't mark a position in the page.
+func isDecorativeLink(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.Data != "a" || isAnchorTarget(node) {
+		return false
+	}
+	text := strings.TrimSpace(textContent(node))
+	if text == "" {
+		for n := range node.Descendants() {
+			if n.Type == html.ElementNode && itemInSlice(n.Data, []string{"img", "video", "audio", "iframe", "embed", "object", "math"}) {
+				return false
+			}
+		}
+		return true
+	}
+	if !strings.HasPrefix(findAttribute(node, "href"), "#") {
+		return false
+	}
+	if hasAnyClass(node, permalinkClasses) || skipLinkRegex.MatchString(text) {
+		return true
+	}
+	// a single symbol like `¶`, `#` or `§` linking to the heading
+	r, size := utf8.DecodeRuneInString(text)
+	return size == len(text) && !unicode.IsLetter(r) && !unicode.IsDigit(r) && nearHeading(node)
+}
+
+// headingText returns the text of a heading, without its permalink anchors.
+func headingText(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+		}
+		for child := range n.ChildNodes() {
+			if !isDecorativeLink(child) {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return strings.TrimSpace(collapseWhitespace(builder.String()))
+}
+
+// isAnchorTarget reports whether the node is an anchor marking a position in
+// the page, like `<a id="x"></a>` or `<a name="x">`, instead of a link.
+func isAnchorTarget(node *html.Node) bool {
+	return node.Type == html.ElementNode && node.Data == "a" && !hasAttribute(node, "href") &&
+		(findAttribute(node, "id") != "" || findAttribute(node, "name") != "")
+}
+
+// anchorTargetID returns the id of an anchor target.
+func anchorTargetID(node *html.Node) string {
+	if id := findAttribute(node, "id"); id != "" {
+		return id
+	}
+	return findAttribute(node, "name")
+}
+
+// isEmptyNode reports whether the node holds no text.
+func isEmptyNode(node *html.Node) bool {
+	return strings.TrimSpace(textContent(node)) == ""
+}
+
+type tocEntry struct {
+	level int
+	text  string
+	id    string
+}
+
+// anchorIndex holds the ids of the headings and anchor targets of a document,
+// and the ids they get in the markdown output.
+type anchorIndex struct {
+	// in-page link targets rewritten to the id of their heading in the output
+	targets map[string]string
+	// id written for the headings, empty when none is written
+	headings map[*html.Node]string
+	toc      []tocEntry
+	skipped  map[*html.Node]bool
+}
+
+// newAnchorIndex collects the headings of the document. Anchor targets inside
+// a heading, or right before it, are ids of the heading and are left out of the
+// conversion.
+func newAnchorIndex(doc *html.Node, style HeadingIDStyle, toc bool) *anchorIndex {
+	index := &anchorIndex{
+		targets:  map[string]string{},
+		headings: map[*html.Node]string{},
+		skipped:  map[*html.Node]bool{},
+	}
+	slugs := map[string]int{}
+
+	for node := range doc.Descendants() {
+		level := headingLevel(node)
+		if level == 0 {
+			continue
+		}
+
+		var ids []string
+		if id := findAttribute(node, "id"); id != "" {
+			ids = append(ids, id)
+		}
+		for n := range node.Descendants() {
+			if isAnchorTarget(n) && isEmptyNode(n) {
+				ids = append(ids, anchorTargetID(n))
+				index.skipped[n] = true
+			}
+		}
+		prev := node.PrevSibling
+		for prev != nil && prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "" {
+			prev = prev.PrevSibling
+		}
+		if prev != nil && isAnchorTarget(prev) && isEmptyNode(prev) {
+			ids = append(ids, anchorTargetID(prev))
+			index.skipped[prev] = true
+		}
+
+		text := headingText(node)
+		slug := githubSlug(text)
+		if count := slugs[slug]; count > 0 {
+			slugs[slug]++
+			slug = fmt.Sprintf("%s-%d", slug, count)
+		} else {
+			slugs[slug] = 1
+		}
+
+		// the id the heading is linked to
+		target := slug
+		switch style {
+		case HeadingIDAttribute, HeadingIDAnchor:
+			if len(ids) > 0 {
+				target = ids[0]
+				index.headings[node] = target
+			} else if toc {
+				index.headings[node] = target
+			}
+		}
+		for _, id := range ids {
+			index.targets[id] = target
+		}
+		index.toc = append(index.toc, tocEntry{level: level, text: text, id: target})
+	}
+	return index
+}
+
+// rewriteHref points in-page links to the id their heading has in the output.
+func (c *Converter) rewriteHref(href string) string {
+	if c.options.headingIDs == HeadingIDNone || !strings.HasPrefix(href, "#") {
+		return href
+	}
+	if target, ok := c.anchors.targets[href[1:]]; ok {
+		return "#" + target
+	}
+	return href
+}
+
+// rewriteURL returns the URL of a link, an image or a media element given by
+// WithURLRewriter.
+func (c *Converter) rewriteURL(url string) string {
+	if c.options.urlRewriter == nil || url == "" {
+		return url
+	}
+	return c.options.urlRewriter(url)
+}
+
+// newAnchorTarget returns the element for an anchor marking a position in the
+// page, which is kept only when heading ids are written.
+func (c *Converter) newAnchorTarget(node *html.Node) MarkdownElement {
+	if c.options.headingIDs == HeadingIDNone {
+		return NewUnknownTag(node.Data)
+	}
+	return NewAnchorTargetTag(anchorTargetID(node), c.options.headingIDs, c.options.flavor)
+}
+
+// tableOfContents returns a list linking to the headings of the document.
+func (c *Converter) tableOfContents() string {
+	if len(c.anchors.toc) == 0 {
+		return ""
+	}
+	top := c.anchors.toc[0].level
+	for _, entry := range c.anchors.toc {
+		top = min(top, entry.level)
+	}
+
+	var builder strings.Builder
+	depth := -1
+	for _, entry := range c.anchors.toc {
+		// a list can be nested only one level deeper than its parent
+		depth = min(entry.level-top, depth+1)
+		item := NewListItemTag(depth, UnorderedList, "0", c.options.bullets[depth%len(c.options.bullets)], c.options.listIndent)
+		for range depth {
+			builder.WriteString(item.container().prefix)
+		}
+		text := escapeMinimal(entry.text, escapeContext{flavor: c.options.flavor, last: true, insideLink: true})
+		fmt.Fprintf(&builder, "%s[%s](#%s)\n", item.StartCode(), text, entry.id)
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// isTableOfContentsMarker reports whether the node is a `<!-- toc -->` comment.
+func isTableOfContentsMarker(node *html.Node) bool {
+	return node.Type == html.CommentNode && strings.EqualFold(strings.TrimSpace(node.Data), "toc")
+}
@@ -0,0 +1,190 @@
+package html2md
+
+import "testing"
+
+func TestHeadingIDs(t *testing.T) {
+	page := `<h1 id="intro">Introduction</h1><p>See <a href="#usage-section">usage</a>.</p>` +
+		`<h2><a name="usage-section"></a>Usage &amp; Setup</h2><p><a id="note"></a>A note, <a href="#note">link</a>.</p>`
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Ids are dropped by default",
+			input:    page,
+			expected: "# Introduction\nSee [usage](#usage-section).\n\n## Usage & Setup\nA note, [link](#note).\n\n",
+		},
+		{
+			name:     "Links rewritten to slugs",
+			options:  []Option{WithHeadingIDs(HeadingIDSlug)},
+			input:    page,
+			expected: "# Introduction\nSee [usage](#usage--setup).\n\n## Usage & Setup\n<a id=\"note\"></a>A note, [link](#note).\n\n",
+		},
+		{
+			name:     "Id attributes",
+			options:  []Option{WithHeadingIDs(HeadingIDAttribute)},
+			input:    page,
+			expected: "# Introduction {#intro}\nSee [usage](#usage-section).\n\n## Usage & Setup {#usage-section}\n[]{#note}A note, [link](#note).\n\n",
+		},
+		{
+			name:     "HTML anchors",
+			options:  []Option{WithHeadingIDs(HeadingIDAnchor)},
+			input:    page,
+			expected: "# <a id=\"intro\"></a>Introduction\nSee [usage](#usage-section).\n\n## <a id=\"usage-section\"></a>Usage & Setup\n<a id=\"note\"></a>A note, [link](#note).\n\n",
+		},
+		{
+			name:     "Anchor before the heading",
+			options:  []Option{WithHeadingIDs(HeadingIDSlug)},
+			input:    `<p><a href="#old">jump</a></p><a name="old"></a>` + "\n" + `<h3>New Title</h3>`,
+			expected: "[jump](#new-title)\n\n### New Title\n",
+		},
+		{
+			name:     "Duplicate slugs",
+			options:  []Option{WithHeadingIDs(HeadingIDSlug)},
+			input:    `<h2 id="a">Notes</h2><h2 id="b">Notes</h2><p><a href="#b">second</a></p>`,
+			expected: "## Notes\n## Notes\n[second](#notes-1)\n\n",
+		},
+		{
+			name:     "Closed setext heading with attribute",
+			options:  []Option{WithHeadingIDs(HeadingIDAttribute), WithHeadingStyle(HeadingSetext), WithClosingSequence(true)},
+			input:    `<h1 id="top">Top</h1><h3 id="low">Low</h3>`,
+			expected: "Top {#top}\n==========\n### Low ### {#low}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "At the top",
+			options:  []Option{WithTableOfContents(true)},
+			input:    `<h1>Guide</h1><h2>Install</h2><h4>From *source*</h4><h2>Use</h2>`,
+			expected: "- [Guide](#guide)\n\t- [Install](#install)\n\t\t- [From \\*source\\*](#from-source)\n\t- [Use](#use)\n\n# Guide\n## Install\n#### From \\*source\\*\n## Use\n",
+		},
+		{
+			name:     "At the marker",
+			options:  []Option{WithTableOfContents(true), WithListIndent(IndentSpaces(2))},
+			input:    `<h1>Title</h1><p>Intro</p><!-- toc --><h2 id="one">One</h2><h3>Two</h3>`,
+			expected: "# Title\nIntro\n\n- [Title](#title)\n  - [One](#one)\n    - [Two](#two)\n\n## One\n### Two\n",
+		},
+		{
+			name:     "Links to written ids",
+			options:  []Option{WithTableOfContents(true), WithHeadingIDs(HeadingIDAttribute)},
+			input:    `<h2 id="custom">One</h2><h2>Two</h2>`,
+			expected: "- [One](#custom)\n- [Two](#two)\n\n## One {#custom}\n## Two {#two}\n",
+		},
+		{
+			name:     "Document without headings",
+			options:  []Option{WithTableOfContents(true)},
+			input:    `<p>Text</p>`,
+			expected: "Text\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestDecorativeLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Sphinx headerlink",
+			input:    `<h2 id="title">Title<a class="headerlink" href="#title" title="Link to this heading">¶</a></h2>`,
+			expected: "## Title\n",
+		},
+		{
+			name:     "MkDocs permalink",
+			input:    `<h3 id="setup">Setup <a class="headerlink" href="#setup">#</a></h3>`,
+			expected: "### Setup\n",
+		},
+		{
+			name:     "Docusaurus hash link",
+			input:    `<h2 class="anchor" id="usage">Usage<a href="#usage" class="hash-link" aria-label="Direct link to Usage">​</a></h2>`,
+			expected: "## Usage\n",
+		},
+		{
+			name:     "GitHub anchor next to the heading",
+			input:    `<div class="markdown-heading"><h2 class="heading-element">Install</h2><a id="user-content-install" class="anchor" aria-label="Permalink: Install" href="#install"><svg class="octicon"><path d="M0"></path></svg></a></div>`,
+			expected: "## Install\n",
+		},
+		{
+			name:     "Symbol link before the heading text",
+			input:    `<h4><a href="#api">§</a> API</h4>`,
+			expected: "#### API\n",
+		},
+		{
+			name:     "Skip to content link",
+			input:    `<a class="skip-link" href="#main">Skip to main content</a><p>Body</p>`,
+			expected: "Body\n\n",
+		},
+		{
+			name:     "Empty anchors",
+			input:    `<p>Text<a href="https://example.com"></a> <a href="/x"><span> </span></a>end</p>`,
+			expected: "Text end\n\n",
+		},
+		{
+			name:     "Empty anchor without href",
+			input:    `<p>a<a></a>b</p>`,
+			expected: "ab\n\n",
+		},
+		{
+			name:     "Image links are kept",
+			input:    `<p><a href="/big.png"><img src="/small.png" alt="photo"></a></p>`,
+			expected: "[![photo](/small.png)\n](/big.png)\n\n",
+		},
+		{
+			name:     "Symbol links outside headings are kept",
+			input:    `<p>See note <a href="#note">*</a> and <a href="#top">skip this section</a>.</p>`,
+			expected: "See note [\\*](#note) and [skip this section](#top).\n\n",
+		},
+		{
+			name:     "Slug without the permalink",
+			input:    `<h2>Title <a class="headerlink" href="#title">¶</a></h2>`,
+			expected: "- [Title](#title)\n\n## Title\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithTableOfContents(test.name == "Slug without the permalink")).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package html2md
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// calloutTypes maps the names used by documentation generators to the
+// callout types of Obsidian, which are used as the canonical ones.
+var calloutTypes = map[string]string{
+	"note": "note", "seealso": "note", "primary": "note", "secondary": "note", "light": "note", "dark": "note",
+	"abstract": "abstract", "summary": "abstract", "tldr": "abstract",
+	"info": "info", "todo": "todo",
+	"tip": "tip", "hint": "tip", "important": "important",
+	"success": "success", "check": "success", "done": "success",
+	"question": "question", "help": "question", "faq": "question",
+	"warning": "warning", "attention": "warning",
+	"caution": "caution",
+	"failure": "failure", "fail": "failure", "missing": "failure",
+	"danger": "danger", "error": "danger",
+	"bug": "bug", "example": "example", "quote": "quote", "cite": "quote",
+}
+
+// gitHubAlerts maps the canonical callout types to the five GitHub alerts.
+var gitHubAlerts = map[string]string{
+	"note": "NOTE", "abstract": "NOTE", "info": "NOTE", "todo": "NOTE", "question": "NOTE",
+	"example": "NOTE", "quote": "NOTE",
+	"tip": "TIP", "success": "TIP",
+	"important": "IMPORTANT", "warning": "WARNING",
+	"caution": "CAUTION", "failure": "CAUTION", "danger": "CAUTION", "bug": "CAUTION",
+}
+
+// class prefixes followed by the callout type, as in `alert-info` (Bootstrap),
+// `markdown-alert-note` (GitHub) or `theme-admonition-tip` (Docusaurus).
+var calloutClassPrefixes = []string{"alert-", "alert--", "markdown-alert-", "theme-admonition-", "admonition-", "callout-"}
+
+// classes of the element holding the title of a callout.
+var calloutTitleClasses = []string{"admonition-title", "admonition-heading", "alert-heading", "markdown-alert-title", "callout-title"}
+
+// findCallout reports the callout type and title of the node, if it is an
+// admonition, alert or callout box. The title node is nil when the callout has
+// no explicit title.
+func findCallout(node *html.Node) (type_ string, title *html.Node, ok bool) {
+	if !itemInSlice(node.Data, []string{"div", "aside", "section", "blockquote"}) {
+		return "", nil, false
+	}
+
+	classes := strings.Fields(findAttribute(node, "class"))
+	switch {
+	case itemInSlice("admonition", classes):
+		type_ = "note"
+	case itemInSlice("callout", classes) && findAttribute(node, "data-callout") != "":
+		type_ = calloutTypes[strings.ToLower(findAttribute(node, "data-callout"))]
+	case itemInSlice("alert", classes), itemInSlice("markdown-alert", classes), itemInSlice("theme-admonition", classes):
+	case node.Data == "aside":
+	default:
+		return "", nil, false
+	}
+
+	// the first class naming a type wins, e.g. `theme-admonition-tip alert alert--success`
+	for _, class := range slices.Backward(classes) {
+		class = strings.ToLower(class)
+		for _, prefix := range calloutClassPrefixes {
+			if t, found := calloutTypes[strings.TrimPrefix(class, prefix)]; found && strings.HasPrefix(class, prefix) {
+				type_ = t
+			}
+		}
+		if t, found := calloutTypes[class]; found {
+			type_ = t
+		}
+	}
+	if type_ == "" {
+		return "", nil, false
+	}
+
+	for n := range node.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if hasAnyClass(n, calloutTitleClasses) || strings.Contains(findAttribute(n, "class"), "admonitionHeading") {
+			title = n
+			break
+		}
+	}
+	return type_, title, true
+}
+
+// calloutTitle returns the text of the title node, which is empty when the
+// title only repeats the callout type.
+func calloutTitle(type_ string, title *html.Node) string {
+	if title == nil {
+		return ""
+	}
+	text := strings.TrimSpace(collapseWhitespace(textContent(title)))
+	if t, ok := calloutTypes[strings.ToLower(text)]; ok && t == type_ {
+		return ""
+	}
+	return text
+}
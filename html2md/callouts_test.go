@@ -0,0 +1,67 @@
+package html2md
+
+import "testing"
+
+func TestCallouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		flavor   Flavor
+		input    string
+		expected string
+	}{
+		{
+			name:     "MkDocs admonition as GitHub alert",
+			flavor:   GFM,
+			input:    `<div class="admonition warning"><p class="admonition-title">Warning</p><p>Do not <b>touch</b>.</p><p>Really.</p></div><p>After</p>`,
+			expected: "> [!WARNING]\n> Do not **touch**.\n> \n> Really.\n\nAfter\n\n",
+		},
+		{
+			name:     "Custom title as GitHub alert",
+			flavor:   GFM,
+			input:    `<div class="admonition danger"><p class="admonition-title">Data loss</p><p>Back up first.</p></div>`,
+			expected: "> [!CAUTION]\n> **Data loss**\n> \n> Back up first.\n\n",
+		},
+		{
+			name:     "Bootstrap alert and aside",
+			flavor:   GFM,
+			input:    `<div class="alert alert-success" role="alert">Saved.</div><aside class="note">Aside.</aside>`,
+			expected: "> [!TIP]\n> Saved.\n\n> [!NOTE]\n> Aside.\n\n",
+		},
+		{
+			name:     "Docusaurus admonition as Obsidian callout",
+			flavor:   Obsidian,
+			input:    `<div class="theme-admonition theme-admonition-tip alert alert--success"><div class="admonitionHeading_Gvgb"><span><svg></svg></span>Pro tip</div><div class="admonitionContent_BuS1"><p>Use it.</p></div></div>`,
+			expected: "> [!tip] Pro tip\n> Use it.\n\n",
+		},
+		{
+			name:     "GitHub alert as Pandoc div",
+			flavor:   Pandoc,
+			input:    `<p>Before</p><div class="markdown-alert markdown-alert-important"><p class="markdown-alert-title"><svg></svg>Important</p><p>Read this.</p></div>`,
+			expected: "Before\n\n::: important\nRead this.\n\n:::\n\n",
+		},
+		{
+			name:     "Pandoc div with title",
+			flavor:   Pandoc,
+			input:    `<div class="admonition hint"><p class="admonition-title">Shortcut</p><p>Press F1.</p></div>`,
+			expected: "::: tip\n::: title\nShortcut\n:::\n\nPress F1.\n\n:::\n\n",
+		},
+		{
+			name:     "Plain blockquote and div are untouched",
+			flavor:   GFM,
+			input:    `<blockquote>Quote</blockquote><div class="note">Not a callout</div>`,
+			expected: "> Quote\n\nNot a callout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithFlavor(test.flavor)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package html2md
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// cdataRegex matches the CDATA sections of the storage format, which HTML
+// parsers read as comments ending at the first `>`.
+var cdataRegex = regexp.MustCompile(`(?s)<!\[CDATA\[(.*?)\]\]>`)
+
+// selfClosingRegex matches the self-closing Confluence elements, like
+// `<ri:page ri:content-title="Home" />`, which HTML parsers read as open.
+var selfClosingRegex = regexp.MustCompile(`<((?:ac|ri):[\w-]+)([^<>]*?)\s*/>`)
+
+// confluenceCallouts maps the Confluence macros of admonitions to the
+// canonical callout types.
+var confluenceCallouts = map[string]string{
+	"info": "info", "note": "note", "warning": "warning", "tip": "tip",
+}
+
+// confluenceInput rewrites the storage format so that it is parsed like
+// XML: the CDATA sections become text and the self-closing elements are
+// closed.
+func confluenceInput(input string) string {
+	input = cdataRegex.ReplaceAllStringFunc(input, func(cdata string) string {
+		return html.EscapeString(cdataRegex.FindStringSubmatch(cdata)[1])
+	})
+	return selfClosingRegex.ReplaceAllString(input, "<$1$2></$1>")
+}
+
+// confluenceChild returns the first child element of the node with the tag,
+// or nil.
+func confluenceChild(node *html.Node, tag string) *html.Node {
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// macroParameter returns the `<ac:parameter>` node of the macro with the
+// name, or nil.
+func macroParameter(macro *html.Node, name string) *html.Node {
+	for child := range macro.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "ac:parameter" && findAttribute(child, "ac:name") == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// resourcePath returns the relative path of a page or an attachment
+// resource: the pages of the space are siblings, named after their title,
+// the pages of other spaces are in the folder of their space, and the
+// attachments are next to the page.
+func resourcePath(resource *html.Node) string {
+	switch resource.Data {
+	case "ri:page", "ri:blog-post":
+		path := url.PathEscape(findAttribute(resource, "ri:content-title")) + ".md"
+		if space := findAttribute(resource, "ri:space-key"); space != "" {
+			path = "../" + url.PathEscape(space) + "/" + path
+		}
+		return path
+	case "ri:attachment":
+		return url.PathEscape(findAttribute(resource, "ri:filename"))
+	case "ri:url":
+		return findAttribute(resource, "ri:value")
+	}
+	return ""
+}
+
+// resourceName returns the text shown for a resource without link body.
+func resourceName(resource *html.Node) string {
+	switch resource.Data {
+	case "ri:page", "ri:blog-post":
+		return findAttribute(resource, "ri:content-title")
+	case "ri:attachment":
+		return findAttribute(resource, "ri:filename")
+	case "ri:user":
+		return findAttribute(resource, "ri:username")
+	}
+	return ""
+}
+
+// linkResource returns the `ri:` element of a link or an image, or nil.
+func linkResource(node *html.Node) *html.Node {
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && strings.HasPrefix(child.Data, "ri:") {
+			return child
+		}
+	}
+	return nil
+}
+
+// newConfluenceElement creates the element for a node of the Confluence
+// storage format: the code macro is a fenced code block, the admonition
+// macros are callouts and the expand macro is a collapsible section. The
+// attachments and the links to pages become relative paths.
+func (c *Converter) newConfluenceElement(node *html.Node) MarkdownElement {
+	switch node.Data {
+	case "ac:structured-macro", "ac:macro":
+		name := findAttribute(node, "ac:name")
+		switch {
+		case name == "code" || name == "noformat":
+			// the body is the code inside the block, like `<code>` in `<pre>`
+			c.preTagCount++
+			return NewPreTag()
+		case confluenceCallouts[name] != "":
+			return c.newCallout(confluenceCallouts[name], macroParameter(node, "title"))
+		case name == "expand":
+			return c.newSection(macroParameter(node, "title"), false)
+		case name == "toc":
+			// the table of contents is generated from the headings
+			return NewDroppedTag()
+		}
+
+	case "ac:plain-text-body":
+		if c.preTagCount > 0 {
+			c.codeTagCount++
+			language := ""
+			if parameter := macroParameter(node.Parent, "language"); parameter != nil {
+				language = strings.TrimSpace(textContent(parameter))
+			}
+			return NewFencedCodeTag(language)
+		}
+
+	case "ac:parameter":
+		// the parameters are read by their macro
+		return NewDroppedTag()
+
+	case "ac:image":
+		resource := linkResource(node)
+		if resource == nil {
+			return NewDroppedTag()
+		}
+		src, title := resourcePath(resource), findAttribute(node, "ac:title")
+		return NewImageTag(src, imageAlt(findAttribute(node, "ac:alt"), "", title, src), title)
+
+	case "ac:link":
+		href := ""
+		resource := linkResource(node)
+		if resource != nil {
+			href = resourcePath(resource)
+		}
+		if anchor := findAttribute(node, "ac:anchor"); anchor != "" {
+			href += "#" + url.PathEscape(anchor)
+		}
+		if confluenceChild(node, "ac:plain-text-link-body") == nil && confluenceChild(node, "ac:link-body") == nil {
+			// the link shows the name of its resource
+			name := findAttribute(node, "ac:anchor")
+			if resource != nil {
+				name = resourceName(resource)
+			}
+			node.AppendChild(&html.Node{Type: html.TextNode, Data: name})
+		}
+		if resource != nil && resource.Data == "ri:user" {
+			// the users have no page in the export
+			return NewUnknownTag(node.Data)
+		}
+		c.output.insideAnchor = true
+		return NewAnchorTag(href, "")
+	}
+
+	if strings.HasPrefix(node.Data, "ri:") {
+		// the resources are read by their link or image
+		return NewDroppedTag()
+	}
+	return NewUnknownTag(node.Data)
+}
@@ -0,0 +1,64 @@
+package html2md
+
+import "testing"
+
+func TestConfluencePreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Code macro",
+			input: `<ac:structured-macro ac:name="code" ac:schema-version="1"><ac:parameter ac:name="language">go</ac:parameter>` +
+				`<ac:parameter ac:name="title">main.go</ac:parameter><ac:plain-text-body><![CDATA[if a > b && c < d {
+	fmt.Println("<b>")
+}]]></ac:plain-text-body></ac:structured-macro><p>After</p>`,
+			expected: "```go\nif a > b && c < d {\n\tfmt.Println(\"<b>\")\n}\n```\nAfter\n\n",
+		},
+		{
+			name: "Callouts",
+			input: `<ac:structured-macro ac:name="info"><ac:parameter ac:name="title">Heads up</ac:parameter>` +
+				`<ac:rich-text-body><p>Info <em>text</em>.</p></ac:rich-text-body></ac:structured-macro>` +
+				`<ac:structured-macro ac:name="warning"><ac:rich-text-body><p>Careful.</p></ac:rich-text-body></ac:structured-macro>`,
+			expected: "> [!NOTE]\n> **Heads up**\n> \n> Info *text*.\n\n> [!WARNING]\n> Careful.\n\n",
+		},
+		{
+			name: "Expand macro",
+			input: `<ac:structured-macro ac:name="expand"><ac:parameter ac:name="title">More</ac:parameter>` +
+				`<ac:rich-text-body><p>Hidden body.</p></ac:rich-text-body></ac:structured-macro>` +
+				`<ac:structured-macro ac:name="toc" ac:schema-version="1" /><p>End</p>`,
+			expected: "<details>\n<summary>More</summary>\n\nHidden body.\n\n</details>\n\nEnd\n\n",
+		},
+		{
+			name: "Links",
+			input: `<p><ac:link><ri:page ri:content-title="Getting Started" /></ac:link>, ` +
+				`<ac:link><ri:page ri:space-key="OPS" ri:content-title="Runbook" /><ac:plain-text-link-body><![CDATA[the runbook]]></ac:plain-text-link-body></ac:link>, ` +
+				`<ac:link ac:anchor="Install"><ac:link-body>below <b>here</b></ac:link-body></ac:link>, ` +
+				`<ac:link><ri:attachment ri:filename="spec.pdf" /></ac:link> by <ac:link><ri:user ri:username="jdoe" /></ac:link>.</p>`,
+			expected: "[Getting Started](Getting%20Started.md), [the runbook](../OPS/Runbook.md), [below **here**](#Install), [spec.pdf](spec.pdf) by jdoe.\n\n",
+		},
+		{
+			name:     "Images",
+			input:    `<p><ac:image ac:alt="Diagram"><ri:attachment ri:filename="arch diagram.png" /></ac:image></p><p><ac:image><ri:url ri:value="https://example.com/x.png" /></ac:image></p>`,
+			expected: "![Diagram](arch%20diagram.png)\n\n![x](https://example.com/x.png)\n\n",
+		},
+		{
+			name:     "Image without alt",
+			input:    `<p><ac:image ac:title="The picture"><ri:attachment ri:filename="pic.png" /></ac:image></p><p><ac:image><ri:attachment ri:filename="pic.png" /></ac:image></p>`,
+			expected: "![The picture](pic.png \"The picture\")\n\n![pic](pic.png)\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithPreset(PresetConfluence)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package html2md
 
 import (
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -12,26 +14,58 @@ var languageRegex = regexp.MustCompile(`language-(\w+)`)
 var ignoreTags = []string{"script", "style"}
 
 type Converter struct {
-	// todo add options
+	options            options
 	listStack          *stack[*listEntry]
-	processed          map[string]bool
 	preTagCount        int
 	output             *outputWriter
 	codeTagCount       int
 	codeContentWritten bool
+	footnotes          *footnoteIndex
+	skipped            map[*html.Node]bool // nodes left out of the conversion
+	headingShift       int                 // levels added to the headings to normalize them
+	anchors            *anchorIndex
+	tocWritten         bool
+	sectionLevel       int // level of the last heading written
+	detailsDepth       int
+	lineDepth          int // rendering a heading or another single line
+	cellDepth          int // rendering a table cell
+	formats            *stack[inlineFormat]
+	openFormats        []formatKind // emphasis delimiters open in the output
+	// the trailing space of the last text of an element is trimmed, and only
+	// written when inline content follows the element
+	trimmedSpace bool
 }
 
-// NewConverter creates a converter instance. 
+// NewConverter creates a converter instance, configured by the given options.
 // Each converter must be used **exactly once** for an input, and is NOT thread-safe.
-func NewConverter() *Converter {
+func NewConverter(opts ...Option) *Converter {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.lineBreakStyle == BreakDefault {
+		options.lineBreakStyle = BreakSpaces
+		if options.flavor == CommonMark || options.flavor == Pandoc {
+			options.lineBreakStyle = BreakBackslash
+		}
+	}
 	stack := newStack[*listEntry]()
+	output := newOutputWriter()
+	output.wrapWidth = options.wrapWidth
+	output.sentenceBreaks = options.sentenceBreaks
+	output.abbreviations = map[string]bool{}
+	for _, abbreviation := range slices.Concat(defaultAbbreviations, options.abbreviations) {
+		output.abbreviations[strings.ToLower(abbreviation)] = true
+	}
 	return &Converter{
+		options:            options,
 		listStack:          stack,
-		processed:          map[string]bool{},
+		formats:            newStack[inlineFormat](),
 		preTagCount:        0,
 		codeTagCount:       0,
-		output:             newOutputWriter(),
+		output:             output,
 		codeContentWritten: false,
+		skipped:            map[*html.Node]bool{},
 	}
 }
 
@@ -46,6 +80,48 @@ func findAttribute(node *html.Node, key string) string {
 	return ""
 }
 
+// hasAttribute reports whether the node has the attribute, even when its value is empty.
+func hasAttribute(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setAttribute sets the value of the attribute, adding it when the node doesn't have it.
+func setAttribute(node *html.Node, key, val string) {
+	for i, attr := range node.Attr {
+		if attr.Key == key {
+			node.Attr[i].Val = val
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// hasAnyClass reports whether the class attribute of the node contains any of the given classes.
+func hasAnyClass(node *html.Node, classes []string) bool {
+	for _, class := range strings.Fields(findAttribute(node, "class")) {
+		if itemInSlice(class, classes) {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns the concatenated text of all the descendants of the node.
+func textContent(node *html.Node) string {
+	var builder strings.Builder
+	for n := range node.Descendants() {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+		}
+	}
+	return builder.String()
+}
+
 func findCodeLanguage(node *html.Node) string {
 	if node.Type != html.ElementNode && node.Data != "code" {
 		panic("attempt to find language in a non-code tag")
@@ -127,11 +203,13 @@ func (c *Converter) writeText(text string, trimTrailingSpace bool) {
 			text = "\n\n"
 		case newlineCount == 1:
 			text = "\n"
+		case prefix == " " || c.output.endsWithWhitespace():
+			// the prefix or the output already separates the words
+			text = ""
 		default:
 			text = " "
 		}
 	} else {
-		text = escapeMarkdown(text)
 		text = collapseWhitespace(text)
 		if text == "" {
 			return
@@ -145,6 +223,7 @@ func (c *Converter) writeText(text string, trimTrailingSpace bool) {
 		}
 
 		if trimTrailingSpace {
+			defer func(spaced bool) { c.trimmedSpace = spaced }(strings.HasSuffix(text, " "))
 			text = strings.TrimRight(text, " ")
 			if text == "" {
 				return
@@ -162,23 +241,51 @@ func (c *Converter) writeText(text string, trimTrailingSpace bool) {
 		text = prefix + text
 	}
 
-	c.output.WriteString(text)
+	if strings.TrimSpace(text) != "" {
+		if prefix == "" {
+			c.writeTrimmedSpace()
+		}
+		c.trimmedSpace = false
+		// the emphasis opens after the spaces
+		lead := text[:len(text)-len(strings.TrimLeft(text, " \n"))]
+		c.output.writeProse(lead)
+		c.syncFormats()
+		text = text[len(lead):]
+	}
+	c.output.writeProse(c.escape(text, trimTrailingSpace))
+}
+
+// writeTrimmedSpace writes the trimmed trailing space of the previous text
+// before inline content.
+func (c *Converter) writeTrimmedSpace() {
+	if c.trimmedSpace && !c.output.endsWithWhitespace() {
+		c.output.writeProse(" ")
+	}
+	c.trimmedSpace = false
+}
+
+// ignored reports whether the node and its children are left out of the conversion.
+func (c *Converter) ignored(node *html.Node) bool {
+	if isMathScript(node) || isEmbedScript(node) {
+		return false
+	}
+	return itemInSlice(node.Data, ignoreTags) || isRenderedMath(node) || isDecorativeLink(node) || isHidden(node) || c.skipped[node]
 }
 
 func (c *Converter) htmlNodeToMarkdownElement(node *html.Node) MarkdownElement {
+	if math := findMath(node, c.options.flavor); math != nil {
+		return math
+	}
+	if media := c.findMedia(node); media != nil {
+		return media
+	}
+	if c.options.preset == PresetConfluence && (strings.HasPrefix(node.Data, "ac:") || strings.HasPrefix(node.Data, "ri:")) {
+		return c.newConfluenceElement(node)
+	}
+
 	switch node.Data {
-	case "h1":
-		return NewH1Tag()
-	case "h2":
-		return NewH2Tag()
-	case "h3":
-		return NewH3Tag()
-	case "h4":
-		return NewH4Tag()
-	case "h5":
-		return NewH5Tag()
-	case "h6":
-		return NewH6Tag()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return c.newHeading(node)
 
 	case "b", "strong":
 		return NewBoldTag()
@@ -188,72 +295,75 @@ func (c *Converter) htmlNodeToMarkdownElement(node *html.Node) MarkdownElement {
 		return NewParagraphTag()
 
 	case "a":
-		href := findAttribute(node, "href")
+		if isAnchorTarget(node) {
+			return c.newAnchorTarget(node)
+		}
+		href := c.rewriteURL(c.rewriteHref(findAttribute(node, "href")))
 		title := findAttribute(node, "title")
 		c.output.insideAnchor = true
 		return NewAnchorTag(href, title)
 
 	case "img":
-		src := findAttribute(node, "src")
-		alt := findAttribute(node, "alt")
-		if alt == "" {
-			alt = "image"
-		}
-		return NewImageTag(src, alt)
+		return c.newImage(node)
+
+	case "figure":
+		return c.newFigure(node)
+
+	case "details":
+		return c.newDetails(node)
+
+	case "del", "s", "strike":
+		return NewStrikethroughTag(c.options.flavor)
+
+	case "dt":
+		return NewDefinitionTermTag(c.options.flavor)
+	case "dd":
+		return NewDefinitionTag(c.options.flavor)
+
+	case "table":
+		return c.newTable(node)
 
 	case "ul":
-		fingerprint := generateFingerprint(node)
-		if _, ok := c.processed[fingerprint]; !ok {
-			// this tag has not been processed before
-			c.listStack.push(newUnorderedListEntry())
-			c.processed[fingerprint] = true
-			depth := c.listStack.size() - 1
-			return NewListTag(UnorderedList, depth)
-		}
-		return NewUnknownTag(node.Data)
+		c.listStack.push(newUnorderedListEntry(node))
+		depth := c.listStack.size() - 1
+		return NewListTag(UnorderedList, depth)
 	case "ol":
-		fingerprint := generateFingerprint(node)
-		if _, ok := c.processed[fingerprint]; !ok {
-			// this tag has not been processed before
-			type_ := findAttribute(node, "type")
-			cType, case_ := getOrderedListParams(type_)
-
-			start := findAttribute(node, "start")
-			if start == "" {
-				start = "1"
-			}
-			startNum, err := strconv.Atoi(start)
-			if err != nil {
-				startNum = 1
-			}
-			c.listStack.push(newOrderedListEntry(startNum, cType, case_))
-			c.processed[fingerprint] = true
-			depth := c.listStack.size() - 1
-			return NewListTag(OrderedList, depth)
-		}
-		return NewUnknownTag(node.Data)
+		c.listStack.push(newOrderedListEntry(node, c.newListCounter(node)))
+		depth := c.listStack.size() - 1
+		return NewListTag(OrderedList, depth)
 
 	case "li":
 		topmost, err := c.listStack.top()
 		if err != nil {
-			// if list items without a parent ol/ul tags are found,
-			// insert an ul tag in the stack
-			topmost = newUnorderedListEntry()
+			// list items without a parent ol/ul tag form an unordered
+			// list, which ends after the last of the sibling items
+			topmost = newUnorderedListEntry(node.Parent)
+			topmost.orphan = true
 			c.listStack.push(topmost)
-			// panic("li tag without parent ol/ul tag")
 		}
 		depth := c.listStack.size() - 1
 		var number string
 		if topmost.type_ == UnorderedList {
 			number = "0"
 		} else {
-			number = topmost.counter.next()
+			if value, err := strconv.Atoi(strings.TrimSpace(findAttribute(node, "value"))); err == nil {
+				topmost.counter.Reset(value)
+			}
+			number = topmost.counter.Next()
+			if topmost.first == "" {
+				topmost.first = number
+			} else if c.options.listNumbering == NumberRepeat {
+				number = topmost.first
+			}
 		}
-		return NewListItemTag(depth, topmost.type_, number)
+		bullet := c.options.bullets[depth%len(c.options.bullets)]
+		return NewListItemTag(depth, topmost.type_, number, bullet, c.options.listIndent)
 
 	case "blockquote":
-		c.output.addBlockquote()
-		return NewBlockquoteTag(c.listStack.size() > 0)
+		if type_, title, ok := findCallout(node); ok {
+			return c.newCallout(type_, title)
+		}
+		return NewBlockquoteTag()
 
 	case "pre":
 		c.preTagCount++
@@ -263,42 +373,173 @@ func (c *Converter) htmlNodeToMarkdownElement(node *html.Node) MarkdownElement {
 		// use fenced code block when inside a `pre` tag
 		// similar implementation to list stacks
 		// for fenced code blocks, language is important too
-		c.codeTagCount++
 		if c.preTagCount == 0 {
+			// the emphasis around the code opens before it, there is none inside
+			c.writeTrimmedSpace()
+			c.syncFormats()
+			c.codeTagCount++
 			return NewInlineCodeTag()
 		}
+		c.codeTagCount++
 		language := findCodeLanguage(node)
 		return NewFencedCodeTag(language)
 
 	case "br":
-		return NewBRTag()
+		return c.newLineBreak(node)
 
 	case "hr":
 		return NewHRTag()
 
 	default:
-		return NewUnknownTag(node.Data)
+		if type_, title, ok := findCallout(node); ok {
+			return c.newCallout(type_, title)
+		}
+		if isCheckbox(node) {
+			return NewTaskMarkerTag(hasAttribute(node, "checked"), c.options.flavor)
+		}
+		return c.newUnknownElement(node)
+	}
+}
+
+// siblingElement returns the next or previous sibling of the node, skipping
+// whitespace and comments, or nil.
+func siblingElement(node *html.Node, next bool) *html.Node {
+	for {
+		if next {
+			node = node.NextSibling
+		} else {
+			node = node.PrevSibling
+		}
+		if node == nil || node.Type == html.ElementNode || node.Type == html.TextNode && strings.TrimSpace(node.Data) != "" {
+			return node
+		}
+	}
+}
+
+func isLineBreak(node *html.Node) bool {
+	return node != nil && node.Type == html.ElementNode && node.Data == "br"
+}
+
+// endsBlock reports whether the node is the last content of its block, only
+// followed by whitespace.
+func endsBlock(node *html.Node) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n != node && n.Type == html.ElementNode && itemInSlice(n.Data, htmlBlockTags) {
+			return true
+		}
+		if siblingElement(n, true) != nil {
+			return false
+		}
 	}
+	return true
+}
+
+// newLineBreak creates the element for a br node. Consecutive breaks end the
+// paragraph, except in single lines and table cells, and breaks which wouldn't
+// end a line of text are dropped.
+func (c *Converter) newLineBreak(node *html.Node) MarkdownElement {
+	if c.output.isEmpty() || c.output.atBlockStart() || endsBlock(node) {
+		return NewDroppedTag()
+	}
+	if c.lineDepth == 0 && c.cellDepth == 0 {
+		if isLineBreak(siblingElement(node, false)) {
+			return NewDroppedTag()
+		}
+		if isLineBreak(siblingElement(node, true)) {
+			return NewParagraphBreakTag()
+		}
+	}
+	return NewBRTag(c.options.lineBreakStyle, c.lineDepth > 0, c.cellDepth > 0)
+}
+
+// renderLine converts the children of the node into a single line, like the
+// text of a heading.
+func (c *Converter) renderLine(node *html.Node) string {
+	c.lineDepth++
+	defer func() { c.lineDepth-- }()
+	return strings.Join(strings.Fields(c.renderChildren(node)), " ")
+}
+
+// newCallout creates the callout element for an admonition node, its title is
+// written by the callout itself and left out of the conversion.
+func (c *Converter) newCallout(type_ string, title *html.Node) *CalloutTag {
+	if title != nil {
+		c.skipped[title] = true
+	}
+	return NewCalloutTag(type_, calloutTitle(type_, title), c.options.flavor)
 }
 
 func (c *Converter) convertNode(node *html.Node) {
 	switch node.Type {
 	case html.TextNode:
+		if (isList(node.Parent) || node.Parent != nil && node.Parent.Data == "dl") && strings.TrimSpace(node.Data) == "" {
+			// whitespace between list items and definitions is not content
+			return
+		}
+		if isLineBreak(node.PrevSibling) && strings.TrimSpace(node.Data) == "" {
+			// the line break already ended the line
+			return
+		}
 		c.writeText(node.Data, node.NextSibling == nil)
 
+	case html.CommentNode:
+		if c.options.tableOfContents && !c.tocWritten && isTableOfContentsMarker(node) {
+			if !c.output.isEmpty() && !c.output.endsWithNewline() {
+				c.output.WriteString("\n")
+			}
+			c.output.WriteString(c.tableOfContents())
+			c.tocWritten = true
+		}
+
 	case html.ElementNode:
-		if itemInSlice(node.Data, ignoreTags) {
+		if c.ignored(node) {
+			return
+		}
+		if label, ok := c.footnotes.refs[node]; ok {
+			c.output.WriteString(c.footnoteReference(label))
 			return
 		}
 
 		// Determine the Markdown type
 		markdownElem := c.htmlNodeToMarkdownElement(node)
-		if markdownElem.Type() == FencedCode && !c.output.isEmpty() && !c.output.endsWithNewline() {
+		format, formatted := c.elementFormat(node, markdownElem)
+		if formatted {
+			c.formats.push(format)
+			defer func() {
+				c.formats.pop()
+				c.closeFormats(c.currentFormat())
+			}()
+		}
+		isFormat := itemInSlice(markdownElem.Type(), formatElements)
+		isInline := itemInSlice(markdownElem.Type(), inlineElements)
+		if isInline {
+			c.writeTrimmedSpace()
+			c.syncFormats()
+		} else if !isFormat && markdownElem.StartCode() != "" {
+			c.trimmedSpace = false
+			c.closeFormats(inlineFormat{})
+		}
+
+		if itemInSlice(markdownElem.Type(), freshLineElements) && !c.output.isEmpty() && !c.output.endsWithNewline() {
 			c.output.WriteString("\n")
 		}
 
-		// Write opening Markdown syntax
-		c.output.WriteString(markdownElem.StartCode())
+		// the lines after the one holding the opening syntax of a container
+		// are prefixed by its markers or indentation
+		container, isContainer := markdownElem.(containerElement)
+		if isContainer {
+			c.output.pushContainer(container.container().kind, container.container().prefix)
+		}
+
+		// Write opening Markdown syntax, the delimiters of emphasis are
+		// written where the text starts
+		if !isFormat {
+			c.output.WriteString(markdownElem.StartCode())
+		}
+		if itemInSlice(markdownElem.Type(), leafElements) {
+			c.output.WriteString(markdownElem.EndCode())
+			return
+		}
 		if markdownElem.Type() == FencedCode {
 			c.codeContentWritten = false
 		}
@@ -309,13 +550,18 @@ func (c *Converter) convertNode(node *html.Node) {
 		}
 
 		// Write closing Markdown syntax
-		endCode := markdownElem.EndCode()
-		if markdownElem.Type() == Blockquote {
-			// doing this before writing the endcode of blockquote
-			// to prevent `>` in trailing newlines
-			c.output.removeBlockquote()
+		if !isFormat && !isInline && markdownElem.EndCode() != "" {
+			c.trimmedSpace = false
+			c.closeFormats(inlineFormat{})
+		}
+		if isContainer {
+			// doing this before writing the endcode of the container
+			// to prevent its prefix in trailing newlines
+			c.output.popContainer(container.container().kind)
+		}
+		if !isFormat {
+			c.output.WriteString(markdownElem.EndCode())
 		}
-		c.output.WriteString(endCode)
 
 		if markdownElem.Type() == Pre {
 			c.preTagCount--
@@ -326,13 +572,10 @@ func (c *Converter) convertNode(node *html.Node) {
 			}
 		} else if markdownElem.Type() == Anchor {
 			c.output.insideAnchor = false
-		} else if markdownElem.Type() == ListItem && node.NextSibling == nil {
-			// last li tag in a list
-			_, err := c.listStack.pop()
-			if err != nil {
-				// stack underflow
-				// panic("no items in listStack to pop for the last li tag")
-			}
+		} else if markdownElem.Type() == Details {
+			c.detailsDepth--
+		} else if markdownElem.Type() == List || markdownElem.Type() == ListItem && c.endsOrphanList(node) {
+			c.listStack.pop()
 			c.output.WriteString("\n") // write an extra newline when the list ends
 		}
 
@@ -343,26 +586,59 @@ func (c *Converter) convertNode(node *html.Node) {
 // An error is returned only when the HTML input is malformed and cannot be parsed.
 // The input is assumed to be UTF-8 encoded.
 func (c *Converter) ConvertString(input string) (string, error) {
+	if c.options.preset == PresetConfluence {
+		input = confluenceInput(input)
+	}
+
 	// Parse the HTML input into a document tree
 	doc, err := html.Parse(strings.NewReader(input))
 	if err != nil {
 		return "", err
 	}
 
+	switch c.options.preset {
+	case PresetWord:
+		cleanWordHTML(doc)
+	case PresetGoogleDocs:
+		cleanGoogleDocsHTML(doc)
+	}
+
+	if c.options.normalizeHeadings {
+		c.headingShift = 1 - topHeadingLevel(doc)
+	}
+	c.footnotes = newFootnoteIndex(doc)
+	maps.Copy(c.skipped, c.footnotes.skipped)
+	c.anchors = newAnchorIndex(doc, c.options.headingIDs, c.options.tableOfContents)
+	maps.Copy(c.skipped, c.anchors.skipped)
+
 	// Start recursive conversion from the root node's children
 	for node := doc.FirstChild; node != nil; node = node.NextSibling {
 		c.convertNode(node)
 	}
+	c.closeFormats(inlineFormat{})
+	c.writeFootnoteDefinitions()
 
+	if c.options.tableOfContents && !c.tocWritten {
+		return c.tableOfContents() + c.output.String(), nil
+	}
 	return c.output.String(), nil
 }
 
-func escapeMarkdown(text string) string {
-	// Escape special Markdown characters
-	replacer := strings.NewReplacer(
-		`\`, `\\`, `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
-		`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`,
-		`+`, `\+`, `-`, `\-`, `!`, `\!`,
-	)
-	return replacer.Replace(text)
+// renderChildren converts the children of the node into a separate markdown
+// string, leaving the main output untouched. Its prose is wrapped and broken
+// into sentences like the main output, except in single lines and table cells.
+func (c *Converter) renderChildren(node *html.Node) string {
+	output, openFormats := c.output, c.openFormats
+	c.output, c.openFormats = newOutputWriter(), nil
+	if c.lineDepth == 0 && c.cellDepth == 0 {
+		c.output.wrapWidth, c.output.sentenceBreaks = output.wrapWidth, output.sentenceBreaks
+		c.output.abbreviations = output.abbreviations
+	}
+	for child := range node.ChildNodes() {
+		c.convertNode(child)
+	}
+	c.closeFormats(inlineFormat{})
+	rendered := c.output.String()
+	c.output, c.openFormats = output, openFormats
+	return rendered
 }
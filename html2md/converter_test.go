@@ -50,12 +50,12 @@ func TestConvertString(t *testing.T) {
 		{
 			name:     "Escaping Special Characters",
 			input:    `<p>*Markdown* needs escaping: [link]</p>`,
-			expected: `\*Markdown\* needs escaping: \[link\]` + "\n\n",
+			expected: `\*Markdown\* needs escaping: [link]` + "\n\n",
 		},
 		{
 			name:     "More escaping",
 			input:    `<h2># Heading #</h2><p># failed heading #hashtag</p>`,
-			expected: `## \# Heading \#` + "\n" + `\# failed heading \#hashtag` + "\n\n",
+			expected: `## \# Heading \#` + "\n" + `\# failed heading #hashtag` + "\n\n",
 		},
 		{
 			name:     "Unordered List",
@@ -77,6 +77,11 @@ func TestConvertString(t *testing.T) {
 			input:    `<li>hello</li><li>world</li>`,
 			expected: "- hello\n- world\n\n",
 		},
+		{
+			name:     "Whitespace between inline elements",
+			input:    `<p><b>bold</b> <i>italic</i></p>`,
+			expected: "**bold** *italic*\n\n",
+		},
 		{
 			name:     "Hyperlink",
 			input:    `<p>Visit <a href="https://example.com">example</a>.</p>`,
@@ -89,9 +94,7 @@ func TestConvertString(t *testing.T) {
 Line 3
 </a>`,
 			expected: `[Line 1  
-\
 **Line 2**  
-\
 Line 3
 ](/post)`,
 		},
@@ -118,7 +121,7 @@ Line 3
 		{
 			name:     "Complex Document with Images",
 			input:    `<h1>Gallery</h1><p>Check this out: <img src="https://example.com/cat.png" alt="A cat" /></p><img src="https://example.com/dog.png" />`,
-			expected: "# Gallery\nCheck this out: ![A cat](https://example.com/cat.png)\n\n![image](https://example.com/dog.png)\n",
+			expected: "# Gallery\nCheck this out: ![A cat](https://example.com/cat.png)\n\n![dog](https://example.com/dog.png)\n",
 		},
 		{
 			name: "README",
@@ -155,8 +158,7 @@ ananke can read.
 		{
 			name:     "Nested Blockquote",
 			input:    `<blockquote><p>This is a nested blockquote.</p><p>Are you kidding me?</p><blockquote>And this is another level.</blockquote></blockquote>`,
-			expected: "> This is a nested blockquote.\n> \n> Are you kidding me?\n> \n> > And this is another level.\n> \n> ",
-			// * kind of hack here, blockquotes with just newlines are equivalent to not having them at all
+			expected: "> This is a nested blockquote.\n> \n> Are you kidding me?\n> \n> > And this is another level.\n\n",
 		},
 		{
 			name:     "Single Line Break",
@@ -166,12 +168,12 @@ ananke can read.
 		{
 			name:     "Multiple Line Breaks",
 			input:    `<p>First line<br><br>Second line</p>`,
-			expected: "First line  \n  \nSecond line\n\n",
+			expected: "First line\n\nSecond line\n\n",
 		},
 		{
 			name:     "Line Break Between Tags",
 			input:    `<p>First line</p><br><p>Second line</p>`,
-			expected: "First line\n\n  \nSecond line\n\n",
+			expected: "First line\n\nSecond line\n\n",
 		},
 		{
 			name:     "Horizontal Rule",
@@ -191,7 +193,23 @@ ananke can read.
 		{
 			name:     "Nested list with blockquote",
 			input:    `<ul><li>Simple List</li><li><p>Someone once said:</p><blockquote>My famous quote</blockquote><span>by someone</span></li></ul>`,
-			expected: "- Simple List\n- Someone once said:\n\n > My famous quote\n\nby someone\n\n",
+			expected: "- Simple List\n- Someone once said:\n\n\t> My famous quote\n\n\tby someone\n\n",
+		},
+		{
+			name:     "List item with multiple paragraphs",
+			input:    `<ol><li><p>First para.</p><p>Second para.</p></li><li>Next</li></ol>`,
+			expected: "1. First para.\n\n\tSecond para.\n\n2. Next\n\n",
+		},
+		{
+			name: "List item with code block",
+			input: `<ul><li>Run:<pre><code class="language-sh">make
+make install</code></pre></li><li>Done</li></ul>`,
+			expected: "- Run:\n\t```sh\n\tmake\n\tmake install\n\t```\n\n- Done\n\n",
+		},
+		{
+			name:     "Nested list item with block content",
+			input:    `<ul><li>Outer<ul><li><p>Inner one.</p><p>Inner two.</p></li></ul></li><li>After</li></ul>`,
+			expected: "- Outer\n\t- Inner one.\n\n\t\tInner two.\n\n- After\n\n",
 		},
 		{
 			name:     "Ordered list with start",
@@ -223,6 +241,56 @@ ananke can read.
 			input:    `<ol type="5"><li>Five</li><li>Six</li><li>Seven</li></ol>`,
 			expected: "1. Five\n2. Six\n3. Seven\n\n",
 		},
+		{
+			name:     "Reversed ordered list",
+			input:    `<ol reversed><li>Three</li><li>Two</li><li>One</li></ol>`,
+			expected: "3. Three\n2. Two\n1. One\n\n",
+		},
+		{
+			name:     "Reversed ordered list with start",
+			input:    `<ol reversed start="10" type="i"><li>Ten</li><li>Nine</li></ol>`,
+			expected: "x. Ten\nix. Nine\n\n",
+		},
+		{
+			name:     "Reversed ordered list with a start below its items",
+			input:    `<ol reversed start="1"><li>One</li><li>Two</li><li>Three</li></ol>`,
+			expected: "3. One\n2. Two\n1. Three\n\n",
+		},
+		{
+			name:     "List item with value",
+			input:    `<ol><li>One</li><li value="7">Seven</li><li>Eight</li></ol>`,
+			expected: "1. One\n7. Seven\n8. Eight\n\n",
+		},
+		{
+			name:     "Reversed ordered list with item value",
+			input:    `<ol reversed><li>Three</li><li value="10">Ten</li><li>Nine</li></ol>`,
+			expected: "3. Three\n10. Ten\n9. Nine\n\n",
+		},
+		{
+			name:     "List style type lower-greek",
+			input:    `<ol style="list-style-type: lower-greek"><li>Alpha</li><li>Beta</li><li>Gamma</li></ol>`,
+			expected: "α. Alpha\nβ. Beta\nγ. Gamma\n\n",
+		},
+		{
+			name:     "List style type decimal-leading-zero",
+			input:    `<ol start="9" style="color: red; list-style-type: decimal-leading-zero"><li>Nine</li><li>Ten</li></ol>`,
+			expected: "09. Nine\n10. Ten\n\n",
+		},
+		{
+			name:     "List style type overrides type attribute",
+			input:    `<ol type="i" style="list-style-type:upper-latin"><li>First</li><li>Second</li></ol>`,
+			expected: "A. First\nB. Second\n\n",
+		},
+		{
+			name:     "List style shorthand",
+			input:    `<ol style="list-style: inside upper-roman"><li>First</li><li>Second</li></ol>`,
+			expected: "I. First\nII. Second\n\n",
+		},
+		{
+			name:     "Unknown list style type",
+			input:    `<ol type="a" style="list-style-type: klingon"><li>First</li></ol>`,
+			expected: "a. First\n\n",
+		},
 
 		{
 			name:  "Blockquote with Heading, Ordered List, and Nested Blockquote",
@@ -234,8 +302,8 @@ ananke can read.
 > > Another Quote
 > > 
 > > by someone
-> > 
-> > `,
+
+`,
 		},
 		{
 			name:     "Inline Code",
@@ -297,3 +365,115 @@ ananke can read.
 		})
 	}
 }
+
+func TestLineBreaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Trailing spaces",
+			options:  []Option{WithLineBreakStyle(BreakSpaces)},
+			input:    `<p>one<br>two</p>`,
+			expected: "one  \ntwo\n\n",
+		},
+		{
+			name:     "Backslash",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    `<p>one<br>two</p>`,
+			expected: "one\\\ntwo\n\n",
+		},
+		{
+			name:     "HTML",
+			options:  []Option{WithLineBreakStyle(BreakHTML)},
+			input:    `<p>one<br>two</p>`,
+			expected: "one<br>\ntwo\n\n",
+		},
+		{
+			name:     "Default of the flavor",
+			options:  []Option{WithFlavor(CommonMark)},
+			input:    `<p>one<br>two</p>`,
+			expected: "one\\\ntwo\n\n",
+		},
+		{
+			name:     "Consecutive breaks end the paragraph",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    "<p>one<br>\n<br>\n<br>two</p>",
+			expected: "one\n\ntwo\n\n",
+		},
+		{
+			name:     "Breaks at the ends of a block",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    `<p><br>one <b>two<br></b></p><div>three<br><p>four</p></div>`,
+			expected: "one **two**\n\nthree\\\nfour\n\n",
+		},
+		{
+			name:     "List item",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    `<ul><li>one<br>two</li></ul>`,
+			expected: "- one\\\n\ttwo\n\n",
+		},
+		{
+			name:     "Heading",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    `<h2>one<br>two</h2>`,
+			expected: "## one two\n",
+		},
+		{
+			name:     "Table cell",
+			options:  []Option{WithLineBreakStyle(BreakBackslash)},
+			input:    `<table><tr><th>a</th></tr><tr><td>one<br>two<br><br>three</td></tr></table>`,
+			expected: "| a                       |\n| ----------------------- |\n| one<br>two<br><br>three |\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestURLRewriter(t *testing.T) {
+	rewrite := func(url string) string {
+		return strings.Replace(url, ".xhtml", ".md", 1)
+	}
+	input := `<p><a href="ch2.xhtml#s1">next</a> <img src="img/a.xhtml" alt="a"></p><video src="v.xhtml" poster="p.xhtml"></video>`
+	expected := "[next](ch2.md#s1) ![a](img/a.md)\n\n[Video](v.md)\n"
+
+	output, err := NewConverter(WithURLRewriter(rewrite)).ConvertString(input)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if output != expected {
+		t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(expected))
+	}
+}
+
+// paragraphsPage returns a long page of paragraphs, with escaped text and line
+// breaks which read the output written before them.
+func paragraphsPage() string {
+	var builder strings.Builder
+	for range 5000 {
+		builder.WriteString(`<p>1. Some *text* with <b>bold</b> words,<br>a line break and # signs.</p>`)
+	}
+	return builder.String()
+}
+
+func BenchmarkConvertParagraphs(b *testing.B) {
+	input := paragraphsPage()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := NewConverter().ConvertString(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
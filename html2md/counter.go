@@ -1,23 +1,46 @@
 package html2md
 
 import (
-	"fmt"
 	"slices"
 	"strconv"
 	"strings"
 )
 
-type counter interface {
-	next() string
+// Counter generates the numbers of the items of an ordered list.
+type Counter interface {
+	// Next returns the number of the next item.
+	Next() string
+	// Reset sets the value of the next item, as done by `<li value="n">`.
+	Reset(value int)
 }
 
-type counterType uint
+// CounterStyle creates a Counter for an ordered list. `start` is the value of
+// the first item and `step` is added to it for every following item, it is -1
+// for reversed lists.
+type CounterStyle func(start, step int) Counter
+
+// counterStyles are the built-in counter styles, by CSS `list-style-type` name.
+var counterStyles = map[string]CounterStyle{
+	"decimal":              func(start, step int) Counter { return newDecimalCounter(start, step) },
+	"decimal-leading-zero": func(start, step int) Counter { return newLeadingZeroCounter(start, step) },
+	"lower-roman":          func(start, step int) Counter { return newRomanCounter(start, step, lower) },
+	"upper-roman":          func(start, step int) Counter { return newRomanCounter(start, step, upper) },
+	"lower-alpha":          func(start, step int) Counter { return newAlphabetCounter(start, step, lower) },
+	"upper-alpha":          func(start, step int) Counter { return newAlphabetCounter(start, step, upper) },
+	"lower-latin":          func(start, step int) Counter { return newAlphabetCounter(start, step, lower) },
+	"upper-latin":          func(start, step int) Counter { return newAlphabetCounter(start, step, upper) },
+	"lower-greek":          func(start, step int) Counter { return newGreekCounter(start, step) },
+}
 
-const (
-	decimal counterType = iota
-	roman
-	alphabet
-)
+// counterStyleTypes maps the values of the `type` attribute of ol tags to the
+// counter styles.
+var counterStyleTypes = map[string]string{
+	"1": "decimal",
+	"a": "lower-alpha",
+	"A": "upper-alpha",
+	"i": "lower-roman",
+	"I": "upper-roman",
+}
 
 type decimalCounter struct {
 	current int
@@ -31,11 +54,40 @@ func newDecimalCounter(start, step int) *decimalCounter {
 	}
 }
 
-func (c *decimalCounter) next() string {
+func (c *decimalCounter) Next() string {
 	c.current += c.step
 	return strconv.Itoa(c.current - c.step)
 }
 
+func (c *decimalCounter) Reset(value int) {
+	c.current = value
+}
+
+// leadingZeroCounter is a decimal counter padded to two digits, like `01`.
+type leadingZeroCounter struct {
+	decimalCounter
+}
+
+func newLeadingZeroCounter(start, step int) *leadingZeroCounter {
+	return &leadingZeroCounter{decimalCounter{current: start, step: step}}
+}
+
+func (c *leadingZeroCounter) Next() string {
+	value := c.current
+	c.current += c.step
+	if value < 0 {
+		return "-" + padNumber(-value)
+	}
+	return padNumber(value)
+}
+
+func padNumber(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
 type casing uint
 
 const (
@@ -43,17 +95,36 @@ const (
 	upper
 )
 
+var (
+	lowerLatin = []rune("abcdefghijklmnopqrstuvwxyz")
+	upperLatin = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	// the final sigma `ς` is not used as a counter symbol
+	lowerGreek = []rune("αβγδεζηθικλμνξοπρστυφχψω")
+)
+
 type alphabetCounter struct {
 	current int
 	step    int
-	case_   casing
+	letters []rune
 }
 
 func newAlphabetCounter(start, step int, c casing) *alphabetCounter {
+	letters := lowerLatin
+	if c == upper {
+		letters = upperLatin
+	}
 	return &alphabetCounter{
 		current: start,
 		step:    step,
-		case_:   c,
+		letters: letters,
+	}
+}
+
+func newGreekCounter(start, step int) *alphabetCounter {
+	return &alphabetCounter{
+		current: start,
+		step:    step,
+		letters: lowerGreek,
 	}
 }
 
@@ -76,36 +147,39 @@ func reverseString(input string) string {
 }
 
 func (ac *alphabetCounter) decimalToAlphabet() string {
+	if ac.current <= 0 {
+		// alphabetic counters have no symbols for zero and negative values,
+		// browsers fall back to decimal for them
+		return strconv.Itoa(ac.current)
+	}
+
+	base := len(ac.letters)
 	temp := ac.current
 	value := ""
-	var baseline int
-	if ac.case_ == upper {
-		baseline = 65
-	} else if ac.case_ == lower {
-		baseline = 97
-	} else {
-		panic(fmt.Sprintf("unknown casing passed to alphabet counter: %v", ac.case_))
-	}
 	for temp != 0 {
-		rem := temp % 26
+		rem := temp % base
 		if rem == 0 {
-			// for 'Z'
-			rem = 26
+			// for the last letter, like 'Z'
+			rem = base
 			temp -= 1
 		}
-		temp /= 26
-		value += string(rune(baseline + rem - 1))
+		temp /= base
+		value += string(ac.letters[rem-1])
 	}
 
 	return reverseString(value)
 }
 
-func (ac *alphabetCounter) next() string {
+func (ac *alphabetCounter) Next() string {
 	value := ac.decimalToAlphabet()
 	ac.current += ac.step
 	return value
 }
 
+func (ac *alphabetCounter) Reset(value int) {
+	ac.current = value
+}
+
 type romanCounter struct {
 	current int
 	step    int
@@ -122,6 +196,10 @@ func newRomanCounter(start, step int, c casing) *romanCounter {
 
 func (rc *romanCounter) decimalToRoman() string {
 	num := rc.current
+	if num <= 0 {
+		// there are no roman numerals for zero and negative values
+		return strconv.Itoa(num)
+	}
 	symbol := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
 	if rc.case_ == lower {
 		// convert symbol  to lower case
@@ -148,8 +226,12 @@ func (rc *romanCounter) decimalToRoman() string {
 	return result
 }
 
-func (rc *romanCounter) next() string {
+func (rc *romanCounter) Next() string {
 	value := rc.decimalToRoman()
 	rc.current += rc.step
 	return value
 }
+
+func (rc *romanCounter) Reset(value int) {
+	rc.current = value
+}
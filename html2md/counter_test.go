@@ -12,7 +12,7 @@ func TestDecimalCounter(t *testing.T) {
 	step := 2
 	c := newDecimalCounter(start, step)
 	for i := 0; i < 78; i++ {
-		got := c.next()
+		got := c.Next()
 		expected := strconv.Itoa(start + i*step)
 		if got != expected {
 			t.Errorf("expected `%v`, got `%v`", expected, got)
@@ -35,7 +35,7 @@ func TestAlphabeticCounter(t *testing.T) {
 	lowerExpectedValues := slices.Collect(mapIter(func(s string) string { return strings.ToLower(s) }, slices.Values(upperExpectedValues)))
 
 	for i := 0; i < len(upperExpectedValues); i++ {
-		got := upperCounter.next()
+		got := upperCounter.Next()
 		expected := upperExpectedValues[i]
 		if got != expected {
 			t.Errorf("expected `%v`, got `%v`", expected, got)
@@ -43,7 +43,7 @@ func TestAlphabeticCounter(t *testing.T) {
 	}
 
 	for i := 0; i < len(lowerExpectedValues); i++ {
-		got := lowerCounter.next()
+		got := lowerCounter.Next()
 		expected := lowerExpectedValues[i]
 		if got != expected {
 			t.Errorf("expected `%v`, got `%v`", expected, got)
@@ -66,7 +66,7 @@ func TestRomanCounter(t *testing.T) {
 	lowerExpectedValues := slices.Collect(mapIter(func(s string) string { return strings.ToLower(s) }, slices.Values(upperExpectedValues)))
 
 	for i := 0; i < len(upperExpectedValues); i++ {
-		got := upperCounter.next()
+		got := upperCounter.Next()
 		expected := upperExpectedValues[i]
 		if got != expected {
 			t.Errorf("expected `%v`, got `%v`", expected, got)
@@ -74,10 +74,72 @@ func TestRomanCounter(t *testing.T) {
 	}
 
 	for i := 0; i < len(lowerExpectedValues); i++ {
-		got := lowerCounter.next()
+		got := lowerCounter.Next()
 		expected := lowerExpectedValues[i]
 		if got != expected {
 			t.Errorf("expected `%v`, got `%v`", expected, got)
 		}
 	}
 }
+
+func TestGreekCounter(t *testing.T) {
+	c := newGreekCounter(22, 1)
+	for _, expected := range []string{"χ", "ψ", "ω", "αα", "αβ"} {
+		if got := c.Next(); got != expected {
+			t.Errorf("expected `%v`, got `%v`", expected, got)
+		}
+	}
+}
+
+func TestLeadingZeroCounter(t *testing.T) {
+	c := newLeadingZeroCounter(1, -1)
+	for _, expected := range []string{"01", "00", "-01"} {
+		if got := c.Next(); got != expected {
+			t.Errorf("expected `%v`, got `%v`", expected, got)
+		}
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	counters := []Counter{
+		newDecimalCounter(1, 1),
+		newRomanCounter(1, 1, upper),
+		newAlphabetCounter(1, 1, lower),
+	}
+	expected := [][]string{{"1", "5", "6"}, {"I", "V", "VI"}, {"a", "e", "f"}}
+	for i, c := range counters {
+		got := []string{c.Next()}
+		c.Reset(5)
+		got = append(got, c.Next(), c.Next())
+		if !slices.Equal(got, expected[i]) {
+			t.Errorf("expected `%v`, got `%v`", expected[i], got)
+		}
+	}
+}
+
+type bracketCounter struct {
+	current int
+	step    int
+}
+
+func (c *bracketCounter) Next() string {
+	c.current += c.step
+	return "[" + strconv.Itoa(c.current-c.step) + "]"
+}
+
+func (c *bracketCounter) Reset(value int) {
+	c.current = value
+}
+
+func TestWithCounterStyle(t *testing.T) {
+	style := func(start, step int) Counter { return &bracketCounter{start, step} }
+	converter := NewConverter(WithCounterStyle("Brackets", style))
+	output, err := converter.ConvertString(`<ol reversed style="list-style-type: brackets"><li>Two</li><li>One</li></ol>`)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := "[2]. Two\n[1]. One\n\n"
+	if output != expected {
+		t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(expected))
+	}
+}
@@ -0,0 +1,47 @@
+package html2md
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// findSummary returns the `<summary>` child of a details node, or nil.
+func findSummary(node *html.Node) *html.Node {
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "summary" {
+			return child
+		}
+	}
+	return nil
+}
+
+// newDetails creates the element for a details node, its summary is written
+// by the element itself and left out of the conversion.
+func (c *Converter) newDetails(node *html.Node) *DetailsTag {
+	return c.newSection(findSummary(node), hasAttribute(node, "open"))
+}
+
+// newSection creates the element for a collapsible section with the summary
+// node, which is left out of the conversion, or nil.
+func (c *Converter) newSection(summaryNode *html.Node, open bool) *DetailsTag {
+	// browsers show "Details" for a section without summary
+	summary := "Details"
+	if summaryNode != nil {
+		c.skipped[summaryNode] = true
+		if c.options.detailsStyle == DetailsHTML {
+			// the summary is in an HTML block, where markdown is not read
+			summary = strings.TrimSpace(collapseWhitespace(textContent(summaryNode)))
+		} else {
+			summary = c.renderLine(summaryNode)
+		}
+	}
+
+	c.detailsDepth++
+	var heading *HeadingTag
+	if c.options.detailsStyle == DetailsHeading {
+		level := min(max(c.sectionLevel+c.detailsDepth, 1), 6)
+		heading = NewHeadingTag(level, summary, "", c.options.headingStyle, c.options.closeHeadings, c.options.headingIDs, c.options.flavor)
+	}
+	return NewDetailsTag(summary, open, c.options.detailsStyle, heading)
+}
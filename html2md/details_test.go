@@ -0,0 +1,60 @@
+package html2md
+
+import "testing"
+
+func TestDetails(t *testing.T) {
+	input := `<h2>FAQ</h2>` +
+		`<details><summary>How do <b>I</b> install?</summary><p>Run <code>make</code>.</p>` +
+		`<details open><summary>Nested</summary>Inner text.</details></details>` +
+		`<details><summary>Second</summary>Plain body.</details>`
+
+	tests := []struct {
+		name     string
+		style    DetailsStyle
+		input    string
+		expected string
+	}{
+		{
+			name:     "HTML",
+			style:    DetailsHTML,
+			input:    input,
+			expected: "## FAQ\n<details>\n<summary>How do I install?</summary>\n\nRun `` make ``.\n\n<details open>\n<summary>Nested</summary>\n\nInner text.\n\n</details>\n\n</details>\n\n<details>\n<summary>Second</summary>\n\nPlain body.\n\n</details>\n\n",
+		},
+		{
+			name:     "HTML escapes the summary",
+			style:    DetailsHTML,
+			input:    `<details><summary>a &lt;b&gt; &amp; c</summary>body</details>`,
+			expected: "<details>\n<summary>a &lt;b&gt; &amp; c</summary>\n\nbody\n\n</details>\n\n",
+		},
+		{
+			name:     "Without summary",
+			style:    DetailsHTML,
+			input:    `<details><p>body</p></details>`,
+			expected: "<details>\n<summary>Details</summary>\n\nbody\n\n</details>\n\n",
+		},
+		{
+			name:     "Callout",
+			style:    DetailsCallout,
+			input:    input,
+			expected: "## FAQ\n> [!faq]- How do **I** install?\n> Run `` make ``.\n> \n> > [!faq]+ Nested\n> > Inner text.\n\n> [!faq]- Second\n> Plain body.\n\n",
+		},
+		{
+			name:     "Heading",
+			style:    DetailsHeading,
+			input:    input,
+			expected: "## FAQ\n### How do **I** install?\n\nRun `` make ``.\n\n#### Nested\n\nInner text.\n\n### Second\n\nPlain body.\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithDetailsStyle(test.style)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package html2md
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// markdown punctuation escaped everywhere by EscapeAggressive.
+var aggressiveEscaper = strings.NewReplacer(
+	`\`, `\\`, `*`, `\*`, `_`, `\_`, `{`, `\{`, `}`, `\}`,
+	`[`, `\[`, `]`, `\]`, `(`, `\(`, `)`, `\)`, `#`, `\#`,
+	`+`, `\+`, `-`, `\-`, `!`, `\!`,
+)
+
+func escapeMarkdown(text string) string {
+	// Escape special Markdown characters
+	return aggressiveEscaper.Replace(text)
+}
+
+var entityRegex = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+
+// escapeContext describes where a piece of text is written.
+type escapeContext struct {
+	flavor Flavor
+	// the text starts a new block, at the beginning of a line or after the
+	// markers of its containers
+	blockStart bool
+	// the character written before the text, or 0 when it is unknown
+	previous rune
+	// the text is the last one of its parent, otherwise the character after
+	// it is unknown and it is escaped as if it could start markdown syntax
+	last bool
+	// the text is the content of a link
+	insideLink bool
+}
+
+func isASCIIPunct(r rune) bool {
+	return strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", r)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n'
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// onlyRunes reports whether the line holds only the character r and spaces,
+// and at least min times r.
+func onlyRunes(line string, r rune, min int) bool {
+	count := 0
+	for _, c := range line {
+		switch c {
+		case r:
+			count++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return count >= min
+}
+
+// escapeMinimal escapes the characters of the text which would be read as
+// markdown syntax at their position, and leaves ordinary punctuation alone.
+func escapeMinimal(text string, ctx escapeContext) string {
+	var builder strings.Builder
+	blockStart := ctx.blockStart
+	tildes, dollars := strings.Count(text, "~"), strings.Count(text, "$")
+	// index of the delimiter of a number starting an ordered list
+	delimiter := -1
+	prev := ctx.previous
+	for i, r := range text {
+		rest := text[i:]
+		_, size := utf8.DecodeRuneInString(rest)
+		// the character after r, 0 at the end of the text when unknown
+		next, _ := utf8.DecodeRuneInString(rest[size:])
+		if len(rest) == size {
+			next = 0
+			if ctx.last {
+				next = ' '
+			}
+		}
+		line := func() string {
+			line, _, _ := strings.Cut(rest, "\n")
+			return line
+		}
+
+		escape := i == delimiter
+		if blockStart {
+			switch {
+			case r == '#' || r == '>' || r == '[':
+				escape = true
+			case r == '-' || r == '+' || r == '*':
+				escape = isSpace(next) || next == 0 || onlyRunes(line(), r, 2)
+			case r == '=' || r == '_':
+				escape = onlyRunes(line(), r, 1)
+			case r == '~':
+				escape = strings.HasPrefix(line(), "~~~")
+			case r >= '0' && r <= '9':
+				line := line()
+				digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+				after := strings.TrimLeft(line[digits:], ".)")
+				if digits <= 9 && len(line)-len(after) == digits+1 && (after == "" || after[0] == ' ' || after[0] == '\t') {
+					delimiter = i + digits
+				}
+			}
+		}
+
+		if !escape {
+			switch r {
+			case '\\':
+				// before punctuation or at the end of a line, where it is a hard line break
+				escape = next == 0 || isASCIIPunct(next) || strings.TrimLeft(line()[1:], " \t") == ""
+			case '`':
+				escape = true
+			case '*', '_':
+				// a run surrounded by spaces can't open or close emphasis, and
+				// underscores inside a word don't either,
+				escape = !isSpace(prev) || !isSpace(next)
+				// except in MultiMarkdown, where they do
+				if r == '_' && isAlphanumeric(prev) && isAlphanumeric(next) && ctx.flavor != MultiMarkdown {
+					escape = false
+				}
+			case '[':
+				escape = next == '^' || ctx.flavor == Obsidian && next == '['
+			case ']':
+				escape = ctx.insideLink || next == 0 || next == '(' || next == '[' || next == ':'
+			case '!':
+				escape = next == 0 || next == '['
+			case '<':
+				escape = next == '/' || next == '!' || next == '?' || unicode.IsLetter(next)
+			case '&':
+				escape = entityRegex.MatchString(rest)
+			case '~':
+				escape = tildes > 1
+			case '$':
+				escape = dollars > 1
+			case '#':
+				// the closing sequence of a heading, or a tag in Obsidian
+				afterRun := strings.TrimLeft(line(), "#")
+				startsWord := prev == 0 || isSpace(prev)
+				escape = startsWord && strings.TrimSpace(afterRun) == "" ||
+					ctx.flavor == Obsidian && startsWord && (unicode.IsLetter(next) || next == '_')
+			case '=':
+				escape = ctx.flavor == Obsidian && next == '=' && prev != '='
+			case '%':
+				escape = ctx.flavor == Obsidian && next == '%' && prev != '%'
+			case '{':
+				// Pandoc attributes, or MultiMarkdown CriticMarkup like `{++`
+				escape = ctx.flavor == Pandoc || ctx.flavor == MultiMarkdown && strings.ContainsRune("+-~>=", next)
+			}
+		}
+
+		if escape {
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(r)
+		prev = r
+
+		if r == '\n' {
+			blockStart = true
+		} else if !isSpace(r) {
+			blockStart = false
+		}
+	}
+	return builder.String()
+}
+
+// escape escapes the text written at the current position of the output.
+func (c *Converter) escape(text string, last bool) string {
+	if c.options.escapeMode == EscapeAggressive {
+		return escapeMarkdown(text)
+	}
+	return escapeMinimal(text, escapeContext{
+		flavor:     c.options.flavor,
+		blockStart: c.output.atBlockStart(),
+		previous:   c.output.lastRune(),
+		last:       last,
+		insideLink: c.output.insideAnchor,
+	})
+}
@@ -0,0 +1,157 @@
+package html2md
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"golang.org/x/net/html"
+)
+
+func TestEscaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Ordinary prose",
+			input:    `<p>A well-known trick (see below) costs 5 + 3 = 8! Really.</p>`,
+			expected: "A well-known trick (see below) costs 5 + 3 = 8! Really.\n\n",
+		},
+		{
+			name:     "Headings at line start",
+			input:    `<p># not a heading</p><p>#hashtag and issue #42</p>`,
+			expected: "\\# not a heading\n\n\\#hashtag and issue #42\n\n",
+		},
+		{
+			name:     "List markers at line start",
+			input:    `<p>- not a list</p><p>+ neither</p><p>* nor this</p><p>1. not ordered</p><p>2) nor this</p><p>2024. A year</p><p>3.14 is pi</p>`,
+			expected: "\\- not a list\n\n\\+ neither\n\n\\* nor this\n\n1\\. not ordered\n\n2\\) nor this\n\n2024\\. A year\n\n3.14 is pi\n\n",
+		},
+		{
+			name:     "Markers after list item markers",
+			input:    `<ul><li># one</li><li>- two</li><li>[ ] three</li><li>a - b</li></ul>`,
+			expected: "- \\# one\n- \\- two\n- \\[ ] three\n- a - b\n\n",
+		},
+		{
+			name:     "Blockquote and thematic breaks",
+			input:    `<p>&gt; quoted</p><p>---</p><p>***</p><p>===</p>`,
+			expected: "\\> quoted\n\n\\---\n\n\\*\\*\\*\n\n\\===\n\n",
+		},
+		{
+			name:     "Emphasis",
+			input:    `<p>*bold* and _it_ but snake_case_name and 2 * 3 * 4</p>`,
+			expected: "\\*bold\\* and \\_it\\_ but snake_case_name and 2 * 3 * 4\n\n",
+		},
+		{
+			name:     "Links and images",
+			input:    `<p>See [text](url) and ![alt](src) and [label]: def and [^1] but [just brackets]</p>`,
+			expected: "See [text\\](url) and \\![alt\\](src) and [label\\]: def and \\[^1] but [just brackets]\n\n",
+		},
+		{
+			name:     "Exclamation before a link",
+			input:    `<p>Wow!<a href="/x">link</a></p>`,
+			expected: "Wow\\![link](/x)\n\n",
+		},
+		{
+			name:     "Brackets inside links",
+			input:    `<p><a href="/x">[1]</a></p>`,
+			expected: "[[1\\]](/x)\n\n",
+		},
+		{
+			name:     "Code, html and entities",
+			input:    `<p>Use ` + "`code`" + ` or &lt;div&gt; and &amp;amp; but a &lt; b &amp; c</p>`,
+			expected: "Use \\`code\\` or \\<div> and \\&amp; but a < b & c\n\n",
+		},
+		{
+			name:     "Backslashes",
+			input:    `<p>C:\path\*file* and a \ b</p>`,
+			expected: "C:\\path\\\\\\*file\\* and a \\ b\n\n",
+		},
+		{
+			name:     "Strikethrough and math",
+			input:    `<p>~5 minutes, ~~gone~~, $5 or $10</p>`,
+			expected: "\\~5 minutes, \\~\\~gone\\~\\~, \\$5 or \\$10\n\n",
+		},
+		{
+			name:     "Obsidian syntax",
+			options:  []Option{WithFlavor(Obsidian)},
+			input:    `<p>a #tag, [[wiki]], ==mark== and %%comment%% but C# and a==b</p>`,
+			expected: "a \\#tag, \\[[wiki]], \\==mark\\== and \\%%comment\\%% but C# and a\\==b\n\n",
+		},
+		{
+			name:     "Pandoc attributes",
+			options:  []Option{WithFlavor(Pandoc)},
+			input:    `<h2>Title {#id}</h2>`,
+			expected: "## Title \\{#id}\n",
+		},
+		{
+			name:     "Aggressive mode",
+			options:  []Option{WithEscapeMode(EscapeAggressive)},
+			input:    `<p>*Markdown* needs escaping: [link]</p>`,
+			expected: `\*Markdown\* needs escaping: \[link\]` + "\n\n",
+		},
+		{
+			name:     "Aggressive mode headings",
+			options:  []Option{WithEscapeMode(EscapeAggressive)},
+			input:    `<h2># Heading #</h2><p># failed heading #hashtag</p>`,
+			expected: `## \# Heading \#` + "\n" + `\# failed heading \#hashtag` + "\n\n",
+		},
+		{
+			name:     "Aggressive mode prose",
+			options:  []Option{WithEscapeMode(EscapeAggressive)},
+			input:    `<p>A well-known trick (see below)!</p>`,
+			expected: `A well\-known trick \(see below\)\!` + "\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+// TestEscapingRoundTrip renders the converted text with a CommonMark parser,
+// and checks that it reads as the original plain text.
+func TestEscapingRoundTrip(t *testing.T) {
+	inputs := []string{
+		`# not a heading`,
+		`- not a list`,
+		`1. not ordered`,
+		`> not quoted`,
+		`---`,
+		`*bold* and _it_ but snake_case and 2 * 3`,
+		`[text](url) and ![alt](src) and [label]: def`,
+		"`code` and <div> and &amp;amp; and a < b",
+		`C:\path\*file* and a \ b`,
+		`Title ##`,
+		`well-known (see below) costs 5 + 3!`,
+	}
+	for _, input := range inputs {
+		markdown, err := NewConverter().ConvertString("<p>" + html.EscapeString(input) + "</p>")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var rendered bytes.Buffer
+		if err := goldmark.Convert([]byte(markdown), &rendered); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := html.Parse(&rendered)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.TrimSpace(textContent(doc)); got != input {
+			t.Errorf("expected `%v`, got `%v` from `%v`", input, got, markdown)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package html2md
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// flavorParsers configure goldmark with the extensions of each flavor, as the
+// reference parser of its syntax. Raw HTML is rendered, since the flavors
+// without a syntax fall back to it.
+var flavorParsers = map[Flavor]goldmark.Markdown{
+	CommonMark: goldmark.New(goldmark.WithRendererOptions(html.WithUnsafe())),
+	GFM: goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	),
+	Obsidian: goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	),
+	Pandoc: goldmark.New(
+		goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.TaskList, extension.DefinitionList, extension.Footnote),
+		goldmark.WithParserOptions(parser.WithAttribute()),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	),
+	MultiMarkdown: goldmark.New(
+		goldmark.WithExtensions(extension.Table, extension.DefinitionList, extension.Footnote),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	),
+}
+
+const flavorDocument = `<h2 id="intro">Intro</h2>
+<p>Text <del>old</del> new with <span class="math inline">\(x^2\)</span> and a note<sup><a href="#fn1">1</a></sup>.<br>Next line</p>
+<table><thead><tr><th align="left">Item</th><th>Cost</th></tr></thead>
+<tbody><tr><td>Apple | red</td><td>1<br>2</td></tr></tbody></table>
+<ul><li><input type="checkbox" checked> Done</li><li><input type="checkbox"> Todo</li></ul>
+<dl><dt>Term</dt><dd>Definition</dd></dl>
+<p>Some snake_case_word.</p>
+<ol class="footnotes"><li id="fn1">The note.</li></ol>`
+
+// TestFlavorsReferenceParsers renders the output of each flavor with its
+// reference parser, and checks the HTML of the features of the flavor.
+func TestFlavorsReferenceParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		flavor   Flavor
+		contains []string
+	}{
+		{
+			name:   "CommonMark",
+			flavor: CommonMark,
+			contains: []string{
+				`>Apple | red</td>`, `<del>old</del>`, `<code>x^2</code>`, "<br>\nNext line",
+				`<li>☒ Done</li>`, `<strong>Term</strong>`, `<sup><a href="#fn-1">1</a></sup>`,
+				`<a id="fn-1"></a>The note.`, `snake_case_word`,
+			},
+		},
+		{
+			name:   "GFM",
+			flavor: GFM,
+			contains: []string{
+				`<th style="text-align:left">Item</th>`, `>Apple | red</td>`, `<td>1<br>2</td>`, `<del>old</del>`,
+				`$x^2$`, `<br>`, `checked="" disabled="" type="checkbox"`, `<strong>Term</strong>`,
+				`<a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a>`, `snake_case_word`,
+			},
+		},
+		{
+			name:   "Obsidian",
+			flavor: Obsidian,
+			contains: []string{
+				`>Apple | red</td>`, `<del>old</del>`, `checked="" disabled="" type="checkbox"`,
+				`class="footnote-ref"`,
+			},
+		},
+		{
+			name:   "Pandoc",
+			flavor: Pandoc,
+			contains: []string{
+				`>Apple | red</td>`, `<td>1<br>2</td>`, `<del>old</del>`, `<br>`,
+				`checked="" disabled="" type="checkbox"`, "<dt>Term</dt>\n<dd>Definition</dd>",
+				`class="footnote-ref"`, `snake_case_word`,
+			},
+		},
+		{
+			name:   "MultiMarkdown",
+			flavor: MultiMarkdown,
+			contains: []string{
+				`>Apple | red</td>`, `<del>old</del>`, `$x^2$`, `<br>`, `<li>☒ Done</li>`,
+				"<dt>Term</dt>\n<dd>Definition</dd>", `class="footnote-ref"`, `snake_case_word`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			markdown, err := NewConverter(WithFlavor(test.flavor)).ConvertString(flavorDocument)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var rendered bytes.Buffer
+			if err := flavorParsers[test.flavor].Convert([]byte(markdown), &rendered); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range test.contains {
+				if !strings.Contains(rendered.String(), expected) {
+					t.Errorf("rendered output lacks %q\nMarkdown:\n%s\nRendered:\n%s", expected, markdown, rendered.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFlavors(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Pipe table",
+			input:    `<table><tr><th>Name</th><th style="text-align: center">Qty</th><th align="right">Price</th></tr><tr><td>Pen</td><td>10</td><td>1.5</td></tr><tr><td colspan="2">Total</td><td><b>15</b></td></tr></table>`,
+			expected: "| Name  | Qty |  Price |\n| ----- | :-: | -----: |\n| Pen   | 10  |    1.5 |\n| Total |     | **15** |\n\n",
+		},
+		{
+			name:     "Table caption",
+			options:  []Option{WithFlavor(Pandoc)},
+			input:    `<table><caption>Sizes</caption><tr><td>a</td></tr></table>`,
+			expected: "| a   |\n| --- |\n\nTable: Sizes\n\n",
+		},
+		{
+			name:     "HTML table",
+			options:  []Option{WithFlavor(CommonMark)},
+			input:    "<table>\n\n<tr><td>a</td></tr>\n\n</table><p>after</p>",
+			expected: "<table>\n<tbody><tr><td>a</td></tr>\n</tbody></table>\n\nafter\n\n",
+		},
+		{
+			name:     "HTML table with code in a cell",
+			input:    "<table><tr><th>Code</th></tr><tr><td><pre><code>x\ny</code></pre></td></tr></table>",
+			expected: "<table><tbody><tr><th>Code</th></tr><tr><td><pre><code>x\ny</code></pre></td></tr></tbody></table>\n\n",
+		},
+		{
+			name:     "HTML table with a nested table",
+			input:    `<table><tr><th>Outer</th></tr><tr><td><table><tr><td>a</td><td>b</td></tr></table></td></tr></table>`,
+			expected: "<table><tbody><tr><th>Outer</th></tr><tr><td><table><tbody><tr><td>a</td><td>b</td></tr></tbody></table></td></tr></tbody></table>\n\n",
+		},
+		{
+			name:     "Strikethrough",
+			input:    `<p><s>gone</s> and <strike>old</strike></p>`,
+			expected: "~~gone~~ and ~~old~~\n\n",
+		},
+		{
+			name:     "Strikethrough as HTML",
+			options:  []Option{WithFlavor(MultiMarkdown)},
+			input:    `<p><del>gone</del></p>`,
+			expected: "<del>gone</del>\n\n",
+		},
+		{
+			name:     "Task list",
+			input:    `<ul><li><input type="checkbox" checked disabled>Done</li><li><input type="checkbox"> Todo</li></ul>`,
+			expected: "- [x] Done\n- [ ] Todo\n\n",
+		},
+		{
+			name:     "Definition list",
+			options:  []Option{WithFlavor(Pandoc)},
+			input:    "<dl>\n<dt>Term</dt>\n<dd><p>First</p><p>Second</p></dd>\n<dt>Other</dt>\n<dd>Def</dd>\n</dl>",
+			expected: "Term\n:   First\n\n    Second\n\nOther\n:   Def\n\n",
+		},
+		{
+			name:     "Definition list fallback",
+			input:    `<dl><dt>Term</dt><dd>Def</dd></dl>`,
+			expected: "**Term**\n\nDef\n\n",
+		},
+		{
+			name:     "Math as code",
+			options:  []Option{WithFlavor(CommonMark)},
+			input:    `<p><span class="math inline">\(a+b\)</span></p><span class="math display">\[x\]</span>`,
+			expected: "`` a+b ``\n\n```math\nx\n```\n\n",
+		},
+		{
+			name:     "Hard line break",
+			options:  []Option{WithFlavor(Pandoc)},
+			input:    `<p>one<br>two</p>`,
+			expected: "one\\\ntwo\n\n",
+		},
+		{
+			name:     "Heading attribute",
+			options:  []Option{WithFlavor(MultiMarkdown), WithHeadingIDs(HeadingIDAttribute)},
+			input:    `<h2 id="setup">Setup</h2><p><a id="here"></a>Text</p>`,
+			expected: "## Setup [setup]\n<a id=\"here\"></a>Text\n\n",
+		},
+		{
+			name:     "Intraword underscores",
+			options:  []Option{WithFlavor(MultiMarkdown)},
+			input:    `<p>snake_case and {++added++}</p>`,
+			expected: "snake\\_case and \\{++added++}\n\n",
+		},
+		{
+			name:     "Callout",
+			options:  []Option{WithFlavor(CommonMark)},
+			input:    `<div class="admonition tip"><p class="admonition-title">Remember</p><p>This.</p></div>`,
+			expected: "> **Remember**\n> \n> This.\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
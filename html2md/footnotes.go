@@ -0,0 +1,225 @@
+package html2md
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// class names and roles used by Pandoc, Wikipedia, goldmark/Hugo, kramdown and
+// similar generators to mark the block holding the footnote definitions.
+var footnoteSectionClasses = []string{"footnotes", "footnote-list", "references", "reflist", "endnotes"}
+var footnoteSectionRoles = []string{"doc-endnotes", "doc-footnotes"}
+
+// class names of the anchors pointing from a reference to its note.
+var footnoteRefClasses = []string{"footnote-ref", "footnote-reference", "reference", "noteref"}
+
+// class names and roles of the anchors pointing from a note back to the text.
+var footnoteBackrefClasses = []string{"footnote-back", "footnote-backref", "reversefootnote", "mw-cite-backlink"}
+
+// text used by back-reference anchors.
+var footnoteBackrefSymbols = []string{"↩", "↩\ufe0e", "↩\ufe0f", "^", "↑"}
+
+// footnoteIndex holds the result of the footnote pass over a document.
+// References and definitions are keyed by the node they replace.
+type footnoteIndex struct {
+	refs        map[*html.Node]string // reference node -> label
+	definitions []*footnoteDefinition // in label order
+	skipped     map[*html.Node]bool   // containers and back-references left out of the walk
+}
+
+type footnoteDefinition struct {
+	label string
+	node  *html.Node
+}
+
+// newFootnoteIndex matches footnote references to their definitions in the
+// given document. A reference is an anchor linking to the id of a list item,
+// which is either placed inside a <sup> or marked as a note reference.
+func newFootnoteIndex(doc *html.Node) *footnoteIndex {
+	index := &footnoteIndex{
+		refs:    map[*html.Node]string{},
+		skipped: map[*html.Node]bool{},
+	}
+
+	items := map[string]*html.Node{}
+	var anchors []*html.Node
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if id := findAttribute(node, "id"); id != "" && node.Data == "li" {
+				items[id] = node
+			}
+			if node.Data == "a" && strings.HasPrefix(findAttribute(node, "href"), "#") {
+				anchors = append(anchors, node)
+			}
+		}
+		for child := range node.ChildNodes() {
+			collect(child)
+		}
+	}
+	collect(doc)
+
+	labels := map[*html.Node]string{}
+	addDefinition := func(item *html.Node) string {
+		if label, ok := labels[item]; ok {
+			return label
+		}
+		label := strconv.Itoa(len(index.definitions) + 1)
+		labels[item] = label
+		index.definitions = append(index.definitions, &footnoteDefinition{label: label, node: item})
+		return label
+	}
+
+	var containers []*html.Node
+	for _, anchor := range anchors {
+		item, ok := items[strings.TrimPrefix(findAttribute(anchor, "href"), "#")]
+		if !ok || !isFootnoteReference(anchor) || isAncestor(item, anchor) {
+			continue
+		}
+		if _, seen := labels[item]; !seen {
+			containers = append(containers, footnoteSection(item))
+		}
+		index.refs[referenceNode(anchor)] = addDefinition(item)
+	}
+
+	for _, container := range containers {
+		if index.skipped[container] {
+			continue
+		}
+		index.skipped[container] = true
+		// notes without any reference still belong to the footnotes
+		for item := range container.Descendants() {
+			if item.Type == html.ElementNode && item.Data == "li" && findAttribute(item, "id") != "" {
+				addDefinition(item)
+			}
+		}
+	}
+
+	for _, def := range index.definitions {
+		for node := range def.node.Descendants() {
+			if isFootnoteBackref(node) {
+				index.skipped[node] = true
+			}
+		}
+	}
+
+	return index
+}
+
+// isFootnoteReference reports whether the anchor looks like a note reference
+// rather than an ordinary in-page link to a list item.
+func isFootnoteReference(anchor *html.Node) bool {
+	if findAttribute(anchor, "role") == "doc-noteref" || hasAnyClass(anchor, footnoteRefClasses) {
+		return true
+	}
+	if anchor.Parent != nil && anchor.Parent.Type == html.ElementNode && anchor.Parent.Data == "sup" {
+		return true
+	}
+	for child := range anchor.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "sup" {
+			return true
+		}
+	}
+	return false
+}
+
+// isFootnoteBackref reports whether the node links from a note back to its reference.
+func isFootnoteBackref(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	if hasAnyClass(node, footnoteBackrefClasses) || findAttribute(node, "role") == "doc-backlink" {
+		return true
+	}
+	if node.Data != "a" || !strings.HasPrefix(findAttribute(node, "href"), "#") {
+		return false
+	}
+	return itemInSlice(strings.TrimSpace(textContent(node)), footnoteBackrefSymbols)
+}
+
+// footnoteSection returns the block to leave out of the regular walk for
+// the given definition: the nearest ancestor marked as a footnotes section,
+// or the list holding the definition.
+func footnoteSection(item *html.Node) *html.Node {
+	for node := item.Parent; node != nil; node = node.Parent {
+		if node.Type != html.ElementNode {
+			continue
+		}
+		if hasAnyClass(node, footnoteSectionClasses) ||
+			itemInSlice(findAttribute(node, "role"), footnoteSectionRoles) ||
+			findAttribute(node, "id") == "footnotes" {
+			return node
+		}
+	}
+	return item.Parent
+}
+
+// referenceNode returns the outermost node standing for the reference, so that
+// a <sup> wrapping only the anchor is replaced as well.
+func referenceNode(anchor *html.Node) *html.Node {
+	parent := anchor.Parent
+	if parent == nil || parent.Type != html.ElementNode || parent.Data != "sup" {
+		return anchor
+	}
+	for child := range parent.ChildNodes() {
+		if child == anchor {
+			continue
+		}
+		if child.Type != html.TextNode || strings.TrimSpace(child.Data) != "" {
+			return anchor
+		}
+	}
+	return parent
+}
+
+func isAncestor(ancestor, node *html.Node) bool {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// footnoteReference returns the reference to a note, `[^1]` or, when the flavor
+// has no footnotes, a superscript link to the note.
+func (c *Converter) footnoteReference(label string) string {
+	if !c.options.flavor.supports(footnotesFeature) {
+		return fmt.Sprintf(`<sup>[%v](#fn-%v)</sup>`, label, label)
+	}
+	return "[^" + label + "]"
+}
+
+// writeFootnoteDefinitions writes all the definitions at the end of the output.
+// The body of a definition is a container indented by four spaces. When the
+// flavor has no footnotes, they are an ordered list after a thematic break,
+// whose items are the targets of the references.
+func (c *Converter) writeFootnoteDefinitions() {
+	if len(c.footnotes.definitions) == 0 {
+		return
+	}
+	if !c.output.isEmpty() {
+		c.output.WriteString("\n\n")
+	}
+	supported := c.options.flavor.supports(footnotesFeature)
+	if !supported {
+		c.output.WriteString("---\n\n")
+	}
+	for _, def := range c.footnotes.definitions {
+		body := strings.TrimFunc(c.renderChildren(def.node), unicode.IsSpace)
+		marker, indent := "[^"+def.label+"]: ", "    "
+		if !supported {
+			marker = def.label + ". " + fmt.Sprintf(`<a id="fn-%v"></a>`, def.label)
+			indent = strings.Repeat(" ", len(def.label)+2)
+		}
+		c.output.WriteString(marker)
+		c.output.pushContainer(footnoteContainer, indent)
+		c.output.WriteString(body)
+		c.output.popContainer(footnoteContainer)
+		c.output.WriteString("\n")
+	}
+}
@@ -0,0 +1,58 @@
+package html2md
+
+import "testing"
+
+func TestFootnotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Pandoc footnotes",
+			input: `<p>Text<a href="#fn1" class="footnote-ref" id="fnref1" role="doc-noteref"><sup>1</sup></a>.</p>
+<section id="footnotes" class="footnotes footnotes-end-of-document" role="doc-endnotes"><hr />
+<ol><li id="fn1"><p>The note.<a href="#fnref1" class="footnote-back" role="doc-backlink">↩︎</a></p></li></ol>
+</section>`,
+			expected: "Text[^1].\n\n[^1]: The note.\n",
+		},
+		{
+			name: "Wikipedia references",
+			input: `<p>Claim<sup id="cite_ref-1" class="reference"><a href="#cite_note-1">[1]</a></sup> and another<sup id="cite_ref-2" class="reference"><a href="#cite_note-2">[2]</a></sup>.</p>
+<div class="reflist"><ol class="references">
+<li id="cite_note-1"><span class="mw-cite-backlink"><b><a href="#cite_ref-1">^</a></b></span> <span class="reference-text">A <i>book</i>.</span></li>
+<li id="cite_note-2"><span class="mw-cite-backlink"><b><a href="#cite_ref-2">^</a></b></span> <span class="reference-text">A paper.</span></li>
+</ol></div>`,
+			expected: "Claim[^1] and another[^2].\n\n[^1]: A *book*.\n[^2]: A paper.\n",
+		},
+		{
+			name: "Repeated reference and plain ordered list",
+			input: `<p>One<sup><a href="#n1">1</a></sup>, again<sup><a href="#n1">1</a></sup>.</p>
+<ol><li id="n1">Note &#x21a9; <a href="#top">↩</a></li></ol>`,
+			expected: "One[^1], again[^1].\n\n[^1]: Note ↩\n",
+		},
+		{
+			name: "Multi-paragraph note",
+			input: `<p>Text<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>
+<div class="footnotes" role="doc-endnotes"><hr><ol><li id="fn:1"><p>First.</p><p>Second&#160;<a href="#fnref:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p></li></ol></div>`,
+			expected: "Text[^1]\n\n[^1]: First.\n\n    Second\n",
+		},
+		{
+			name:     "In-page link to a list item is not a footnote",
+			input:    `<p>See <a href="#step">the step</a>.</p><ol><li id="step">Step</li></ol>`,
+			expected: "See [the step](#step).\n\n1. Step\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter().ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
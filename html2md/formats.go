@@ -0,0 +1,139 @@
+package html2md
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// formatKind is a kind of emphasis of the text.
+type formatKind uint
+
+const (
+	boldFormat formatKind = iota
+	italicFormat
+	strikethroughFormat
+)
+
+// inlineFormat is the emphasis of the text, indexed by formatKind.
+type inlineFormat [3]bool
+
+// formatElements only change the emphasis of their content, their delimiters
+// are written by the converter where the emphasis changes.
+var formatElements = []MarkdownElementType{Bold, Italic, Strikethrough}
+
+// inlineElements are written inside the emphasis around them, the other
+// elements end it and it is reopened after them.
+var inlineElements = []MarkdownElementType{Anchor, AnchorTarget, Image, InlineCode, InlineMath, RawHTML}
+
+// isHidden reports whether the inline style of the node hides it.
+func isHidden(node *html.Node) bool {
+	return strings.EqualFold(styleProperty(node, "display"), "none") ||
+		itemInSlice(strings.ToLower(styleProperty(node, "visibility")), []string{"hidden", "collapse"})
+}
+
+// styleFormat applies the `font-weight`, `font-style` and `text-decoration`
+// declarations of the inline style of the node to the format. Weights of at
+// least 600 are bold, and `normal` turns off the emphasis set around the node.
+func styleFormat(node *html.Node, format inlineFormat) inlineFormat {
+	switch weight := strings.ToLower(styleProperty(node, "font-weight")); weight {
+	case "":
+	case "bold", "bolder":
+		format[boldFormat] = true
+	case "normal", "lighter":
+		format[boldFormat] = false
+	default:
+		if n, err := strconv.Atoi(weight); err == nil {
+			format[boldFormat] = n >= 600
+		}
+	}
+
+	switch style := strings.ToLower(styleProperty(node, "font-style")); {
+	case strings.HasPrefix(style, "italic"), strings.HasPrefix(style, "oblique"):
+		format[italicFormat] = true
+	case style == "normal":
+		format[italicFormat] = false
+	}
+
+	// a decoration can't be removed from the content of a decorated element
+	for _, property := range []string{"text-decoration", "text-decoration-line"} {
+		if strings.Contains(strings.ToLower(styleProperty(node, property)), "line-through") {
+			format[strikethroughFormat] = true
+		}
+	}
+	return format
+}
+
+// currentFormat returns the emphasis wanted for the text at the current position.
+func (c *Converter) currentFormat() inlineFormat {
+	format, err := c.formats.top()
+	if err != nil {
+		return inlineFormat{}
+	}
+	return format
+}
+
+// elementFormat returns the emphasis of the content of the element, and
+// whether it differs from the emphasis around it.
+func (c *Converter) elementFormat(node *html.Node, elem MarkdownElement) (inlineFormat, bool) {
+	current := c.currentFormat()
+	format := current
+	switch elem.Type() {
+	case Bold:
+		format[boldFormat] = true
+	case Italic:
+		format[italicFormat] = true
+	case Strikethrough:
+		format[strikethroughFormat] = true
+	}
+	format = styleFormat(node, format)
+	return format, format != current
+}
+
+// delimiter returns the opening or closing delimiter of the emphasis.
+func (c *Converter) delimiter(kind formatKind, closing bool) string {
+	var elem MarkdownElement
+	switch kind {
+	case boldFormat:
+		elem = NewBoldTag()
+	case italicFormat:
+		elem = NewItalicTag()
+	default:
+		elem = NewStrikethroughTag(c.options.flavor)
+	}
+	if closing {
+		return elem.EndCode()
+	}
+	return elem.StartCode()
+}
+
+// closeFormats closes the open emphasis which is not kept, and the emphasis
+// opened after it, which is reopened before the next text.
+func (c *Converter) closeFormats(keep inlineFormat) {
+	i := slices.IndexFunc(c.openFormats, func(kind formatKind) bool { return !keep[kind] })
+	if i < 0 {
+		return
+	}
+	for j := len(c.openFormats) - 1; j >= i; j-- {
+		c.output.writeBeforeSpaces(c.delimiter(c.openFormats[j], true))
+	}
+	c.openFormats = c.openFormats[:i]
+}
+
+// syncFormats closes and opens emphasis so that the output matches the
+// emphasis wanted at the current position. Code has no emphasis.
+func (c *Converter) syncFormats() {
+	if c.codeTagCount > 0 {
+		return
+	}
+	want := c.currentFormat()
+	c.closeFormats(want)
+	for _, kind := range []formatKind{boldFormat, italicFormat, strikethroughFormat} {
+		if want[kind] && !slices.Contains(c.openFormats, kind) {
+			c.output.WriteString(c.delimiter(kind, false))
+			c.openFormats = append(c.openFormats, kind)
+		}
+	}
+}
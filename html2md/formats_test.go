@@ -0,0 +1,94 @@
+package html2md
+
+import "testing"
+
+func TestInlineStyles(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name: "Google Docs",
+			input: `<b style="font-weight:normal;" id="docs-internal-guid-1"><p dir="ltr">` +
+				`<span style="font-weight:700;">Bold</span><span style="font-weight:400;"> and </span>` +
+				`<span style="font-style:italic;">italic </span><span>and </span>` +
+				`<span style="text-decoration:line-through;">gone</span></p></b>`,
+			expected: "**Bold** and *italic* and ~~gone~~\n\n",
+		},
+		{
+			name:     "Both",
+			input:    `<span style="font-weight: bold; font-style: oblique">both</span>`,
+			expected: "***both***",
+		},
+		{
+			name:     "Normal weight cancels bold",
+			input:    `<b>bold <span style="font-weight:normal">plain</span> bold</b>`,
+			expected: "**bold** plain **bold**",
+		},
+		{
+			name:     "Normal style cancels italic",
+			input:    `<p><em>a <span style="font-style:normal">b</span></em></p>`,
+			expected: "*a* b\n\n",
+		},
+		{
+			name:     "Light weight",
+			input:    `<span style="font-weight:300">light</span> <span style="font-weight:600">semibold</span>`,
+			expected: "light **semibold**",
+		},
+		{
+			name:     "Nested",
+			input:    `<b><strong>nested</strong> and <b>more</b></b>`,
+			expected: "**nested and more**",
+		},
+		{
+			name:     "Empty",
+			input:    `<p>a<b></b>b<i> </i>c</p>`,
+			expected: "ab c\n\n",
+		},
+		{
+			name:     "Across blocks",
+			input:    `<b><p>one</p><p>two</p></b>`,
+			expected: "**one**\n\n**two**\n\n",
+		},
+		{
+			name:     "Around a link",
+			input:    `<p><span style="font-weight:700">see <a href="/u">this</a></span></p>`,
+			expected: "**see [this](/u)**\n\n",
+		},
+		{
+			name:     "Not in code",
+			input:    `<code><span style="font-weight:700">x</span></code>`,
+			expected: "`` x ``",
+		},
+		{
+			name:     "Around code",
+			input:    `<p><b><code>c</code></b>, <em><code>e</code></em> and <a href="/u"><code>a</code></a></p>`,
+			expected: "**`` c ``**, *`` e ``* and [`` a ``](/u)\n\n",
+		},
+		{
+			name:     "Hidden",
+			input:    `<p>a <span style="display: none">b</span><span style="visibility:hidden">c</span>d</p>`,
+			expected: "a d\n\n",
+		},
+		{
+			name:     "Strikethrough without support",
+			options:  []Option{WithFlavor(CommonMark)},
+			input:    `<span style="text-decoration: underline line-through">old</span>`,
+			expected: "<del>old</del>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
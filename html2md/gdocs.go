@@ -0,0 +1,187 @@
+package html2md
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssRuleRegex matches the rules of a style sheet, the rules nested in an
+// at-rule like `@media` are matched without it.
+var cssRuleRegex = regexp.MustCompile(`([^{}]*)\{([^{}]*)\}`)
+
+var cssCommentRegex = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// classSelectorRegex matches a selector of a single class, like `.c3`.
+var classSelectorRegex = regexp.MustCompile(`^\.(-?[_a-zA-Z][\w-]*)$`)
+
+// listClassRegex matches the class of a Google Docs list, holding the id of
+// the list and the level, starting at 0, as in `lst-kix_h2kvb3tvx1t9-1`.
+var listClassRegex = regexp.MustCompile(`^lst-kix_(\w+)-(\d+)$`)
+
+// cleanGoogleDocsHTML rewrites the HTML exported by Google Docs: the styles
+// of the classes are written inline, the links through the Google redirect
+// are replaced by their destination, and the nested lists, which are
+// exported as siblings of their parent list, are moved into it.
+func cleanGoogleDocsHTML(doc *html.Node) {
+	rules := classRules(doc)
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			inlineClassStyles(node, rules)
+			if node.DataAtom == atom.A {
+				if href := findAttribute(node, "href"); href != "" {
+					setAttribute(node, "href", unwrapGoogleRedirect(href))
+				}
+			}
+		}
+		nestGoogleDocsLists(node)
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+}
+
+// classRule is a rule of a style sheet selecting a single class.
+type classRule struct {
+	class        string
+	declarations string
+}
+
+// classRules returns the rules of the `<style>` elements selecting a single
+// class, in the order of the style sheets.
+func classRules(doc *html.Node) []classRule {
+	var rules []classRule
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Style {
+			css := cssCommentRegex.ReplaceAllString(textContent(node), "")
+			for _, rule := range cssRuleRegex.FindAllStringSubmatch(css, -1) {
+				// the selectors follow the statements before them, like `@import`
+				selectors := rule[1][strings.LastIndex(rule[1], ";")+1:]
+				for _, selector := range strings.Split(selectors, ",") {
+					if match := classSelectorRegex.FindStringSubmatch(strings.TrimSpace(selector)); match != nil {
+						rules = append(rules, classRule{class: match[1], declarations: strings.TrimSpace(rule[2])})
+					}
+				}
+			}
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return rules
+}
+
+// inlineClassStyles writes the declarations of the rules selecting the classes
+// of the node before its inline style. Like in the cascade, the later rules
+// override the earlier ones and the inline style overrides the rules.
+func inlineClassStyles(node *html.Node, rules []classRule) {
+	classes := strings.Fields(findAttribute(node, "class"))
+	var style strings.Builder
+	for _, rule := range rules {
+		if itemInSlice(rule.class, classes) {
+			style.WriteString(rule.declarations + ";")
+		}
+	}
+	if style.Len() > 0 {
+		setAttribute(node, "style", style.String()+findAttribute(node, "style"))
+	}
+}
+
+// unwrapGoogleRedirect returns the destination of a link through the Google
+// redirect, like `https://www.google.com/url?q=https://example.com&sa=D`, or
+// the link itself.
+func unwrapGoogleRedirect(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || u.Path != "/url" || u.Hostname() != "www.google.com" && u.Hostname() != "google.com" {
+		return href
+	}
+	if target := u.Query().Get("q"); target != "" {
+		return target
+	}
+	return href
+}
+
+// googleDocsListLevel returns the id and the level of a Google Docs list.
+func googleDocsListLevel(node *html.Node) (id string, level int, ok bool) {
+	if !isList(node) {
+		return "", 0, false
+	}
+	for _, class := range strings.Fields(findAttribute(node, "class")) {
+		if match := listClassRegex.FindStringSubmatch(class); match != nil {
+			level, _ = strconv.Atoi(match[2])
+			return match[1], level, true
+		}
+	}
+	return "", 0, false
+}
+
+// lastListItem returns the last `<li>` of the list, or nil.
+func lastListItem(list *html.Node) *html.Node {
+	for child := list.LastChild; child != nil; child = child.PrevSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Li {
+			return child
+		}
+	}
+	return nil
+}
+
+// nestGoogleDocsLists moves the runs of sibling Google Docs lists into each
+// other by level: a list of a deeper level goes in the last item of the list
+// before it, and the items of a list continuing a list of the same level are
+// moved to it.
+func nestGoogleDocsLists(node *html.Node) {
+	type openList struct {
+		id    string
+		level int
+		node  *html.Node
+	}
+
+	var open []openList
+	child := node.FirstChild
+	for child != nil {
+		next := child.NextSibling
+		if child.Type == html.TextNode && strings.TrimSpace(child.Data) == "" {
+			child = next
+			continue
+		}
+		id, level, ok := googleDocsListLevel(child)
+		if !ok {
+			open = nil
+			child = next
+			continue
+		}
+
+		for len(open) > 0 {
+			top := open[len(open)-1]
+			if top.level < level || top.level == level && top.id == id {
+				break
+			}
+			open = open[:len(open)-1]
+		}
+		switch {
+		case len(open) > 0 && open[len(open)-1].level == level:
+			list := open[len(open)-1].node
+			for item := child.FirstChild; item != nil; item = child.FirstChild {
+				child.RemoveChild(item)
+				list.AppendChild(item)
+			}
+			node.RemoveChild(child)
+		case len(open) > 0 && lastListItem(open[len(open)-1].node) != nil:
+			node.RemoveChild(child)
+			lastListItem(open[len(open)-1].node).AppendChild(child)
+			open = append(open, openList{id: id, level: level, node: child})
+		default:
+			open = append(open[:0], openList{id: id, level: level, node: child})
+		}
+		child = next
+	}
+}
@@ -0,0 +1,56 @@
+package html2md
+
+import "testing"
+
+func TestGoogleDocsPreset(t *testing.T) {
+	style := `<style type="text/css">@import url(https://themes.googleusercontent.com/fonts/css?kit=abc);` +
+		`.lst-kix_ab12-0>li:before{content:"\0025cf   "}ul.lst-kix_ab12-0{list-style-type:none}` +
+		`.c1{color:#000000;font-weight:400;text-decoration:none;font-style:normal}` +
+		`.c3{font-weight:700;font-style:normal}.c5{font-style:italic}.c6{text-decoration:line-through}` +
+		`/* .c1{font-weight:700} */.c2,.c9{margin-left:36pt}h1{font-weight:700}</style>`
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Class styles",
+			input: style + `<h1 class="c4"><span class="c1">Heading</span></h1>` +
+				`<p class="c0"><span class="c1">Plain </span><span class="c3">bold</span><span class="c1"> and </span>` +
+				`<span class="c5 c1">italic</span><span class="c1"> and </span><span class="c6">gone</span>` +
+				`<span class="c1" style="font-weight:700"> inline</span></p>`,
+			expected: "# Heading\nPlain **bold** and *italic* and ~~gone~~ **inline**\n\n",
+		},
+		{
+			name: "Redirect links",
+			input: `<p><a href="https://www.google.com/url?q=https://example.com/page?a%3D1%26b%3D2&amp;sa=D&amp;source=editors&amp;ust=1&amp;usg=AOv">redirect</a> ` +
+				`<a href="https://www.google.com/search?q=go">search</a></p>`,
+			expected: "[redirect](https://example.com/page?a=1&b=2) [search](https://www.google.com/search?q=go)\n\n",
+		},
+		{
+			name: "Nested lists",
+			input: `<ul class="c8 lst-kix_ab12-0 start"><li class="c2 li-bullet-0"><span class="c1">One</span></li></ul>` +
+				`<ul class="c8 lst-kix_ab12-1 start"><li class="c9"><span>Nested</span></li><li class="c9"><span>Nested 2</span></li></ul>` +
+				`<ul class="c8 lst-kix_ab12-2 start"><li><span>Deep</span></li></ul>` +
+				`<ul class="c8 lst-kix_ab12-0"><li class="c2"><span>Two</span></li></ul>` +
+				`<ul class="c8 lst-kix_ab12-1 start"><li><span>Under two</span></li></ul>` +
+				`<p><span>Between</span></p>` +
+				`<ol class="c8 lst-kix_cd34-0 start" start="1"><li><span>First</span></li></ol>` +
+				`<ol class="c8 lst-kix_ef56-0 start" start="1"><li><span>Other list</span></li></ol>`,
+			expected: "- One\n\t- Nested\n\t- Nested 2\n\t\t- Deep\n\n- Two\n\t- Under two\n\nBetween\n\n1. First\n\n1. Other list\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithPreset(PresetGoogleDocs)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -3,3 +3,5 @@ module github.com/shravanasati/ananke/html2md
 go 1.23.0
 
 require golang.org/x/net v0.33.0
+
+require github.com/yuin/goldmark v1.8.6
@@ -0,0 +1,35 @@
+package html2md
+
+import (
+	"golang.org/x/net/html"
+)
+
+// headingLevel returns the level of a h1 to h6 node, or 0 for other nodes.
+func headingLevel(node *html.Node) int {
+	if node.Type != html.ElementNode || len(node.Data) != 2 || node.Data[0] != 'h' || node.Data[1] < '1' || node.Data[1] > '6' {
+		return 0
+	}
+	return int(node.Data[1] - '0')
+}
+
+// topHeadingLevel returns the lowest level of the headings of the document, or
+// 1 when it has none.
+func topHeadingLevel(doc *html.Node) int {
+	top := 0
+	for node := range doc.Descendants() {
+		if level := headingLevel(node); level > 0 && (top == 0 || level < top) {
+			top = level
+		}
+	}
+	return max(top, 1)
+}
+
+func (c *Converter) newHeading(node *html.Node) *HeadingTag {
+	level := headingLevel(node) + c.headingShift + c.options.headingOffset
+	level = min(max(level, 1), 6)
+	c.sectionLevel = level
+
+	// a heading is a single line
+	text := c.renderLine(node)
+	return NewHeadingTag(level, text, c.anchors.headings[node], c.options.headingStyle, c.options.closeHeadings, c.options.headingIDs, c.options.flavor)
+}
@@ -0,0 +1,102 @@
+package html2md
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "ATX headings",
+			input:    `<h1>One</h1><h3>Three</h3><h6>Six</h6>`,
+			expected: "# One\n### Three\n###### Six\n",
+		},
+		{
+			name:     "Whitespace inside headings",
+			input:    "<h2>\n  Multi\n  line <em>title</em>\n</h2>",
+			expected: "## Multi line *title*\n",
+		},
+		{
+			name:     "Setext headings",
+			options:  []Option{WithHeadingStyle(HeadingSetext)},
+			input:    `<h1>Title</h1><p>Text</p><h2>Hi</h2><h3>Three</h3>`,
+			expected: "Title\n=====\nText\n\nHi\n---\n### Three\n",
+		},
+		{
+			name:     "Setext heading inside a blockquote",
+			options:  []Option{WithHeadingStyle(HeadingSetext)},
+			input:    `<blockquote><h1>Quoted</h1><p>Text</p></blockquote>`,
+			expected: "> Quoted\n> ======\n> Text\n\n",
+		},
+		{
+			name:     "Empty setext heading",
+			options:  []Option{WithHeadingStyle(HeadingSetext)},
+			input:    `<h1></h1>`,
+			expected: "# \n",
+		},
+		{
+			name:     "Closing sequence",
+			options:  []Option{WithClosingSequence(true)},
+			input:    `<h2>Title</h2><h4>Heading #</h4>`,
+			expected: "## Title ##\n#### Heading \\# ####\n",
+		},
+		{
+			name:     "Heading offset",
+			options:  []Option{WithHeadingOffset(2)},
+			input:    `<h1>One</h1><h2>Two</h2><h5>Five</h5><h6>Six</h6>`,
+			expected: "### One\n#### Two\n###### Five\n###### Six\n",
+		},
+		{
+			name:     "Negative heading offset",
+			options:  []Option{WithHeadingOffset(-1)},
+			input:    `<h1>One</h1><h3>Three</h3>`,
+			expected: "# One\n## Three\n",
+		},
+		{
+			name:     "Normalized headings",
+			options:  []Option{WithNormalizedHeadings(true)},
+			input:    `<h3>Top</h3><h4>Sub</h4><h3>Other</h3>`,
+			expected: "# Top\n## Sub\n# Other\n",
+		},
+		{
+			name:     "Normalized headings with offset",
+			options:  []Option{WithNormalizedHeadings(true), WithHeadingOffset(1)},
+			input:    `<h2>Top</h2><h3>Sub</h3>`,
+			expected: "## Top\n### Sub\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestDeprecatedHeadingTags(t *testing.T) {
+	tags := []MarkdownElement{NewH1Tag(), NewH2Tag(), NewH3Tag(), NewH4Tag(), NewH5Tag(), NewH6Tag()}
+	for i, tag := range tags {
+		if tag.Type() != H1+MarkdownElementType(i) {
+			t.Errorf("unexpected type %d of H%dTag", tag.Type(), i+1)
+		}
+		if expected := strings.Repeat("#", i+1) + " "; tag.StartCode() != expected || tag.EndCode() != "\n" {
+			t.Errorf("unexpected H%dTag: %q %q", i+1, tag.StartCode(), tag.EndCode())
+		}
+	}
+	// the values of the types are kept
+	if H1 != 2 || Paragraph != 8 || Unknown != 19 {
+		t.Errorf("unexpected element types: H1 %d, Paragraph %d, Unknown %d", H1, Paragraph, Unknown)
+	}
+}
@@ -0,0 +1,200 @@
+package html2md
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// attributes used by lazy-loading libraries to hold the real image source,
+// while `src` holds a placeholder.
+var lazySrcAttributes = []string{"data-src", "data-original", "data-lazy-src", "data-lazy"}
+var lazySrcsetAttributes = []string{"data-srcset", "data-lazy-srcset"}
+
+// srcsetCandidate is an image candidate of a srcset attribute, with either a
+// width (`480w`) or a pixel density (`2x`) descriptor.
+type srcsetCandidate struct {
+	url     string
+	width   int
+	density float64
+}
+
+// parseSrcset parses the value of a srcset attribute.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	for _, entry := range strings.Split(srcset, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		candidate := srcsetCandidate{url: fields[0], density: 1}
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			switch {
+			case strings.HasSuffix(descriptor, "w"):
+				candidate.width, _ = strconv.Atoi(strings.TrimSuffix(descriptor, "w"))
+			case strings.HasSuffix(descriptor, "x"):
+				if density, err := strconv.ParseFloat(strings.TrimSuffix(descriptor, "x"), 64); err == nil {
+					candidate.density = density
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// bestSrcsetCandidate returns the url of the candidate matching the width, which
+// is the smallest candidate at least as wide. When width is 0, or no candidate is
+// wide enough, the highest-resolution candidate is returned.
+func bestSrcsetCandidate(candidates []srcsetCandidate, width int) string {
+	var best *srcsetCandidate
+	for i := range candidates {
+		candidate := &candidates[i]
+		switch {
+		case best == nil:
+			best = candidate
+		case width > 0 && candidate.width >= width && (best.width < width || candidate.width < best.width):
+			best = candidate
+		case width > 0 && best.width >= width:
+		case candidate.width > best.width || candidate.width == best.width && candidate.density > best.density:
+			best = candidate
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.url
+}
+
+// imageSource returns the url of the image to use for an img node. It prefers
+// lazy-load attributes over the placeholder in `src`, and picks the best
+// candidate of the srcset of the image and of the sources of its <picture>.
+func imageSource(node *html.Node, width int) string {
+	src := findAttribute(node, "src")
+	for _, attr := range lazySrcAttributes {
+		if value := findAttribute(node, attr); value != "" {
+			src = value
+			break
+		}
+	}
+
+	var candidates []srcsetCandidate
+	srcsets := []*html.Node{node}
+	if node.Parent != nil && node.Parent.Type == html.ElementNode && node.Parent.Data == "picture" {
+		for sibling := range node.Parent.ChildNodes() {
+			if sibling.Type == html.ElementNode && sibling.Data == "source" {
+				srcsets = append(srcsets, sibling)
+			}
+		}
+	}
+	for _, n := range srcsets {
+		srcset := findAttribute(n, "srcset")
+		for _, attr := range lazySrcsetAttributes {
+			if value := findAttribute(n, attr); value != "" {
+				srcset = value
+				break
+			}
+		}
+		candidates = append(candidates, parseSrcset(srcset)...)
+	}
+	if len(candidates) == 0 {
+		return src
+	}
+
+	if src != "" && !strings.HasPrefix(src, "data:") {
+		// src is the 1x candidate of the srcset
+		candidates = append(candidates, srcsetCandidate{url: src, density: 1})
+	}
+	return bestSrcsetCandidate(candidates, width)
+}
+
+// imageFilename returns a readable name from the file name of the image url,
+// or an empty string when the url has no file name.
+func imageFilename(src string) string {
+	if strings.HasPrefix(src, "data:") {
+		return ""
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	return strings.TrimSpace(name)
+}
+
+// figureCaption returns the figcaption of the figure holding the node, if any.
+func figureCaption(node *html.Node) *html.Node {
+	for n := node.Parent; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode || n.Data != "figure" {
+			continue
+		}
+		for child := range n.ChildNodes() {
+			if child.Type == html.ElementNode && child.Data == "figcaption" {
+				return child
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// hasImage reports whether there is an img element inside the node.
+func hasImage(node *html.Node) bool {
+	for n := range node.Descendants() {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Converter) newImage(node *html.Node) *ImageTag {
+	src := c.rewriteURL(imageSource(node, c.options.imageWidth))
+	title := findAttribute(node, "title")
+
+	caption := ""
+	if figcaption := figureCaption(node); figcaption != nil {
+		caption = strings.TrimSpace(collapseWhitespace(textContent(figcaption)))
+	}
+	if c.options.figureCaption == CaptionTitle && caption != "" {
+		title = caption
+	}
+
+	alt := imageAlt(findAttribute(node, "alt"), caption, findAttribute(node, "title"), src)
+	return NewImageTag(src, alt, title)
+}
+
+// imageAlt returns the alt text of an image, or when it has none its caption,
+// its title, the name of its file or "image".
+func imageAlt(alt, caption, title, src string) string {
+	for _, fallback := range []string{caption, title, imageFilename(src), "image"} {
+		if alt != "" {
+			break
+		}
+		alt = fallback
+	}
+	return alt
+}
+
+func (c *Converter) newFigure(node *html.Node) *FigureTag {
+	caption := ""
+	for child := range node.ChildNodes() {
+		if child.Type != html.ElementNode || child.Data != "figcaption" {
+			continue
+		}
+		c.skipped[child] = true
+		if c.options.figureCaption == CaptionItalic || !hasImage(node) {
+			caption = strings.Join(strings.Fields(c.renderChildren(child)), " ")
+		}
+	}
+	return NewFigureTag(caption)
+}
@@ -0,0 +1,86 @@
+package html2md
+
+import "testing"
+
+func TestImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Figure with italic caption",
+			input:    `<figure><img src="/img/cat.png" alt="A cat"><figcaption>The <b>office</b> cat.</figcaption></figure><p>Next</p>`,
+			expected: "![A cat](/img/cat.png)\n\n*The **office** cat.*\n\nNext\n\n",
+		},
+		{
+			name:     "Figure caption as title",
+			options:  []Option{WithFigureCaption(CaptionTitle)},
+			input:    `<figure><img src="/img/cat.png"><figcaption>The "office" cat</figcaption></figure>`,
+			expected: "![The \"office\" cat](/img/cat.png \"The \\\"office\\\" cat\")\n\n",
+		},
+		{
+			name:     "Missing alt text falls back to caption",
+			input:    `<figure><img src="/img/cat.png"><figcaption>Cat</figcaption></figure>`,
+			expected: "![Cat](/img/cat.png)\n\n*Cat*\n\n",
+		},
+		{
+			name:     "Missing alt text falls back to title and file name",
+			input:    `<img src="/a.png" title="Title text"><img src="/img/my-sleepy_cat.jpg?w=200">`,
+			expected: "![Title text](/a.png \"Title text\")\n![my sleepy cat](/img/my-sleepy_cat.jpg?w=200)\n",
+		},
+		{
+			name:     "Lazy-loaded image",
+			input:    `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="/real.png" alt="Real">`,
+			expected: "![Real](/real.png)\n",
+		},
+		{
+			name:     "Highest-resolution srcset candidate",
+			input:    `<img src="/small.png" srcset="/medium.png 2x, /large.png 3x" alt="x">`,
+			expected: "![x](/large.png)\n",
+		},
+		{
+			name:     "Picture sources",
+			input:    `<picture><source type="image/webp" srcset="/a-400.webp 400w, /a-1600.webp 1600w"><source srcset="/a-800.jpg 800w"><img src="/a.jpg" alt="a"></picture>`,
+			expected: "![a](/a-1600.webp)\n",
+		},
+		{
+			name:     "Picture sources with a configured width",
+			options:  []Option{WithImageWidth(600)},
+			input:    `<picture><source type="image/webp" srcset="/a-400.webp 400w, /a-1600.webp 1600w"><source srcset="/a-800.jpg 800w"><img src="/a.jpg" alt="a"></picture>`,
+			expected: "![a](/a-800.jpg)\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestBestSrcsetCandidate(t *testing.T) {
+	candidates := parseSrcset("/320.jpg 320w, /640.jpg 640w,/1280.jpg 1280w")
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{0, "/1280.jpg"},
+		{300, "/320.jpg"},
+		{640, "/640.jpg"},
+		{641, "/1280.jpg"},
+		{5000, "/1280.jpg"},
+	}
+	for _, test := range tests {
+		if got := bestSrcsetCandidate(candidates, test.width); got != test.expected {
+			t.Errorf("width %v: expected `%v`, got `%v`", test.width, test.expected, got)
+		}
+	}
+}
@@ -1,104 +1,105 @@
 package html2md
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
+	"strconv"
 	"strings"
 
 	"golang.org/x/net/html"
 )
 
 type listEntry struct {
+	// the ul or ol node of the list, or the parent of orphan list items
+	node    *html.Node
+	orphan  bool
 	type_   ListOrdering
-	counter counter
+	counter Counter
+	// number of the first item, repeated on every item with NumberRepeat
+	first string
 }
 
-// _newListEntry creates and returns a new *listEntry with the given list ordering and start.
-// `start` parameter is relevant only when `type_` is `Ordered`.
-func _newListEntry(orderType ListOrdering, start int, counterType counterType, case_ casing) *listEntry {
-	entry := &listEntry{type_: orderType}
-	if orderType == OrderedList {
-		switch counterType {
-		case decimal:
-			entry.counter = newDecimalCounter(start, 1)
-		case roman:
-			entry.counter = newRomanCounter(start, 1, case_)
-		case alphabet:
-			entry.counter = newAlphabetCounter(start, 1, case_)
-		default:
-			panic(fmt.Sprintf("unknown counter type: %v", counterType))
-		}
-	}
-
-	return entry
+func newUnorderedListEntry(node *html.Node) *listEntry {
+	return &listEntry{node: node, type_: UnorderedList}
 }
 
-func newUnorderedListEntry() *listEntry {
-	return _newListEntry(UnorderedList, 1, decimal, lower) // last 3 params are irrelevant
+func newOrderedListEntry(node *html.Node, counter Counter) *listEntry {
+	return &listEntry{node: node, type_: OrderedList, counter: counter}
 }
 
-func newOrderedListEntry(start int, counterType counterType, case_ casing) *listEntry {
-	return _newListEntry(OrderedList, start, counterType, case_)
+// isList reports whether the node is an ul or ol element.
+func isList(node *html.Node) bool {
+	return node != nil && node.Type == html.ElementNode && (node.Data == "ul" || node.Data == "ol")
 }
 
-// This function uses the "type" attribute for ol tag to figure out
-// the counter type and casing to be used.
-func getOrderedListParams(typeString string) (counterType, casing) {
-	var countType counterType
-	var case_ casing
+// isCheckbox reports whether the node is the checkbox of a task list item.
+func isCheckbox(node *html.Node) bool {
+	return node.Data == "input" && strings.EqualFold(findAttribute(node, "type"), "checkbox")
+}
 
-	if typeString == "i" {
-		countType = roman
-		case_ = lower
-	} else if typeString == "I" {
-		countType = roman
-		case_ = upper
-	} else if typeString == "a" {
-		countType = alphabet
-		case_ = lower
-	} else if typeString == "A" {
-		countType = alphabet
-		case_ = upper
-	} else {
-		countType = decimal
+// endsOrphanList reports whether the li node is the last of the orphan list
+// items forming the topmost list.
+func (c *Converter) endsOrphanList(node *html.Node) bool {
+	topmost, err := c.listStack.top()
+	if err != nil || !topmost.orphan || topmost.node != node.Parent {
+		return false
 	}
-
-	return countType, case_
+	for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == "li" {
+			return false
+		}
+	}
+	return true
 }
 
-// * This fingerprint method is introduced to uniquely identify a HTML node.
-// * This is because while processing lists, for each li tag, its parent ol/ul tag
-// * would also appear in the traversal. The code written previously assumed
-// * that ol/ul would appear only once. To encounter this incorrect assumption,
-// * the converter keeps a track of list tags it has seen. Thus, even if it appears
-// * again, the converter would just ignore it and NOT add it to the list stack.
-
-// Generate a fingerprint for an HTML node
-func generateFingerprint(node *html.Node) string {
-	var builder strings.Builder
-
-	// Include tag type
-	builder.WriteString(node.Data)
-
-	// Include all attributes
-	for _, attr := range node.Attr {
-		builder.WriteString(fmt.Sprintf("%s=%s;", attr.Key, attr.Val))
+// listStyleType returns the name of the counter style of an ol node, from the
+// `list-style-type` or `list-style` of its inline style, or from its `type`
+// attribute. It is empty when neither names a registered style.
+func listStyleType(node *html.Node, styles map[string]CounterStyle) string {
+	if value := styleProperty(node, "list-style-type"); value != "" {
+		if _, ok := styles[strings.ToLower(value)]; ok {
+			return strings.ToLower(value)
+		}
+	}
+	// the shorthand also holds the position and image of the marker
+	for _, value := range strings.Fields(styleProperty(node, "list-style")) {
+		if _, ok := styles[strings.ToLower(value)]; ok {
+			return strings.ToLower(value)
+		}
 	}
+	return counterStyleTypes[findAttribute(node, "type")]
+}
 
-	// Include parent tag type if it exists
-	if node.Parent != nil {
-		builder.WriteString(fmt.Sprintf("parent=%s;", node.Parent.Data))
+// countListItems returns the number of li children of the list node.
+func countListItems(node *html.Node) int {
+	count := 0
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "li" {
+			count++
+		}
 	}
+	return count
+}
 
-	// Include position among siblings
-	position := 0
-	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
-		position++
+// newListCounter creates the counter for an ol node, honouring its counter
+// style and its `start` and `reversed` attributes. Reversed lists count down
+// from the number of items unless a start is given, and never below 1 as the
+// markers of markdown lists can't be negative.
+func (c *Converter) newListCounter(node *html.Node) Counter {
+	style, ok := c.options.counterStyles[listStyleType(node, c.options.counterStyles)]
+	if !ok {
+		style = counterStyles["decimal"]
 	}
-	builder.WriteString(fmt.Sprintf("position=%d;", position))
 
-	// Hash the constructed string
-	hash := sha256.Sum256([]byte(builder.String()))
-	return hex.EncodeToString(hash[:])
+	step := 1
+	start := 1
+	if hasAttribute(node, "reversed") {
+		step = -1
+		start = countListItems(node)
+	}
+	if value, err := strconv.Atoi(strings.TrimSpace(findAttribute(node, "start"))); err == nil {
+		start = value
+	}
+	if step < 0 {
+		start = max(start, countListItems(node))
+	}
+	return style(start, step)
 }
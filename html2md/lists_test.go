@@ -0,0 +1,152 @@
+package html2md
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestListOptions(t *testing.T) {
+	nested := `<ol start="9"><li>Nine<ul><li><p>Bullet</p><p>More</p><ul><li>Deep</li></ul></li></ul></li><li>Ten</li></ol>`
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Tab indentation",
+			input:    nested,
+			expected: "9. Nine\n\t- Bullet\n\n\t\tMore\n\n\t\t- Deep\n\n10. Ten\n\n",
+		},
+		{
+			name:     "Space indentation",
+			options:  []Option{WithListIndent(IndentSpaces(4))},
+			input:    nested,
+			expected: "9. Nine\n    - Bullet\n\n        More\n\n        - Deep\n\n10. Ten\n\n",
+		},
+		{
+			name:     "Marker aligned indentation",
+			options:  []Option{WithListIndent(IndentMarker)},
+			input:    `<ol start="10"><li>Ten<ul><li><p>Bullet</p><p>More</p></li></ul></li></ol><ol><li>One<ol><li>Nested</li></ol></li></ol>`,
+			expected: "10. Ten\n    - Bullet\n\n      More\n\n1. One\n   1. Nested\n\n",
+		},
+		{
+			name:     "Rotating bullets",
+			options:  []Option{WithBullets("-", "*", "+"), WithListIndent(IndentSpaces(2))},
+			input:    `<ul><li>One<ul id="two"><li>Two<ul id="three"><li>Three<ul id="four"><li>Four</li></ul></li></ul></li></ul></li></ul>`,
+			expected: "- One\n  * Two\n    + Three\n      - Four\n\n",
+		},
+		{
+			name:     "Invalid bullets are ignored",
+			options:  []Option{WithBullets("•", "*")},
+			input:    `<ul><li>One</li></ul>`,
+			expected: "* One\n\n",
+		},
+		{
+			name:     "Repeated numbers",
+			options:  []Option{WithListNumbering(NumberRepeat)},
+			input:    `<ol><li>One</li><li>Two</li><li>Three<ol start="4"><li>Four</li><li>Five</li></ol></li></ol>`,
+			expected: "1. One\n1. Two\n1. Three\n\t4. Four\n\t4. Five\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestIdenticalLists(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Identical lists in separate sections",
+			input:    `<section><ul><li>One</li><li>Two</li></ul></section><section><ul><li>One</li><li>Two</li></ul></section>`,
+			expected: "- One\n- Two\n\n- One\n- Two\n\n",
+		},
+		{
+			name:     "Identical nested lists",
+			input:    `<div><ol><li>A<ul><li>x</li></ul></li></ol></div><div><ol><li>A<ul><li>x</li></ul></li></ol></div>`,
+			expected: "1. A\n\t- x\n\n1. A\n\t- x\n\n",
+		},
+		{
+			name:     "Identical lists at the same depth",
+			input:    `<ul><li>One<ul><li>Two<ul><li>Three</li></ul></li></ul></li></ul>`,
+			expected: "- One\n\t- Two\n\t\t- Three\n\n",
+		},
+		{
+			name: "List ending with whitespace",
+			input: `<ul>
+	<li>One</li>
+	<li>Two</li>
+</ul>
+<p>After</p>`,
+			expected: "- One\n- Two\n\nAfter\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter().ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+// listHeavyPage returns a page of many sections, each holding nested lists.
+func listHeavyPage() string {
+	var builder strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&builder, `<section><h2>Section %d</h2><ul class="items" data-section="%d">`, i, i)
+		for j := range 20 {
+			fmt.Fprintf(&builder, `<li>Item %d<ol type="a"><li>First</li><li>Second</li></ol></li>`, j)
+		}
+		builder.WriteString(`</ul></section>`)
+	}
+	return builder.String()
+}
+
+func BenchmarkConvertLists(b *testing.B) {
+	input := listHeavyPage()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := NewConverter().ConvertString(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// siblingListsPage returns a page of many lists next to each other.
+func siblingListsPage() string {
+	var builder strings.Builder
+	for i := range 5000 {
+		fmt.Fprintf(&builder, `<ol start="%d" class="entry"><li>%d</li></ol>`, i+1, i)
+	}
+	return builder.String()
+}
+
+func BenchmarkConvertSiblingLists(b *testing.B) {
+	input := siblingListsPage()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := NewConverter().ConvertString(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
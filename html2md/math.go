@@ -0,0 +1,304 @@
+package html2md
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+var texCommandSuffix = regexp.MustCompile(`\\[a-zA-Z]+$`)
+
+// isMathScript reports whether the node is a MathJax v2 <script type="math/tex">,
+// which holds the TeX source of the formula rendered next to it.
+func isMathScript(node *html.Node) bool {
+	return node.Data == "script" && strings.HasPrefix(findAttribute(node, "type"), "math/tex")
+}
+
+// isRenderedMath reports whether the node is the rendered output of MathJax,
+// whose source is kept elsewhere (in a math/tex script or assistive MathML).
+func isRenderedMath(node *html.Node) bool {
+	for _, class := range strings.Fields(findAttribute(node, "class")) {
+		if strings.HasPrefix(class, "MathJax") && node.Data != "mjx-container" {
+			return true
+		}
+	}
+	return false
+}
+
+// findMath returns the math element for the node when it is a formula,
+// written either as MathML, by KaTeX, by MathJax or by Pandoc.
+// The returned element is nil when the node isn't a formula.
+func findMath(node *html.Node, flavor Flavor) MarkdownElement {
+	switch {
+	case isMathScript(node):
+		display := strings.Contains(findAttribute(node, "type"), "mode=display")
+		return NewMathTag(textContent(node), display, flavor)
+
+	case hasAnyClass(node, []string{"katex-display"}):
+		return NewMathTag(mathSource(node), true, flavor)
+
+	case hasAnyClass(node, []string{"katex"}):
+		return NewMathTag(mathSource(node), false, flavor)
+
+	case node.Data == "mjx-container":
+		return NewMathTag(mathSource(node), findAttribute(node, "display") == "true", flavor)
+
+	case node.Data == "math":
+		return NewMathTag(mathSource(node), findAttribute(node, "display") == "block" || findAttribute(node, "mode") == "display", flavor)
+
+	case hasAnyClass(node, []string{"math"}) && (hasAnyClass(node, []string{"inline"}) || hasAnyClass(node, []string{"display"})):
+		// pandoc --mathjax/--katex output: <span class="math inline">\(x\)</span>
+		tex := strings.TrimSpace(textContent(node))
+		for _, delims := range [][2]string{{`\(`, `\)`}, {`\[`, `\]`}} {
+			if strings.HasPrefix(tex, delims[0]) && strings.HasSuffix(tex, delims[1]) {
+				tex = tex[len(delims[0]) : len(tex)-len(delims[1])]
+			}
+		}
+		return NewMathTag(tex, hasAnyClass(node, []string{"display"}), flavor)
+	}
+
+	return nil
+}
+
+// mathSource returns the TeX source from the annotation inside the node,
+// falling back to a conversion of the MathML inside it.
+func mathSource(node *html.Node) string {
+	var math *html.Node
+	for n := range node.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if n.Data == "annotation" && findAttribute(n, "encoding") == "application/x-tex" {
+			return textContent(n)
+		}
+		if n.Data == "math" && math == nil {
+			math = n
+		}
+	}
+	if node.Data == "math" {
+		math = node
+	}
+	if math == nil {
+		return strings.TrimSpace(textContent(node))
+	}
+	return mathMLToLaTeX(math)
+}
+
+// greek letters and symbols which have a command in LaTeX.
+var mathMLIdentifiers = map[string]string{
+	"α": `\alpha`, "β": `\beta`, "γ": `\gamma`, "δ": `\delta`, "ε": `\varepsilon`, "ϵ": `\epsilon`,
+	"ζ": `\zeta`, "η": `\eta`, "θ": `\theta`, "ϑ": `\vartheta`, "ι": `\iota`, "κ": `\kappa`,
+	"λ": `\lambda`, "μ": `\mu`, "ν": `\nu`, "ξ": `\xi`, "π": `\pi`, "ρ": `\rho`, "σ": `\sigma`,
+	"ς": `\varsigma`, "τ": `\tau`, "υ": `\upsilon`, "φ": `\varphi`, "ϕ": `\phi`, "χ": `\chi`,
+	"ψ": `\psi`, "ω": `\omega`, "Γ": `\Gamma`, "Δ": `\Delta`, "Θ": `\Theta`, "Λ": `\Lambda`,
+	"Ξ": `\Xi`, "Π": `\Pi`, "Σ": `\Sigma`, "Υ": `\Upsilon`, "Φ": `\Phi`, "Ψ": `\Psi`,
+	"Ω": `\Omega`, "∞": `\infty`, "ℓ": `\ell`, "ℏ": `\hbar`, "∅": `\emptyset`, "ℝ": `\mathbb{R}`,
+	"ℕ": `\mathbb{N}`, "ℤ": `\mathbb{Z}`, "ℚ": `\mathbb{Q}`, "ℂ": `\mathbb{C}`,
+}
+
+// function names written upright in LaTeX.
+var mathMLFunctions = []string{
+	"sin", "cos", "tan", "cot", "sec", "csc", "arcsin", "arccos", "arctan", "sinh", "cosh", "tanh",
+	"log", "ln", "lg", "exp", "lim", "liminf", "limsup", "max", "min", "sup", "inf", "det", "dim",
+	"gcd", "deg", "arg", "ker", "Pr",
+}
+
+var mathMLOperators = map[string]string{
+	"∑": `\sum`, "∏": `\prod`, "∫": `\int`, "∬": `\iint`, "∮": `\oint`, "±": `\pm`, "∓": `\mp`,
+	"×": `\times`, "÷": `\div`, "⋅": `\cdot`, "·": `\cdot`, "∘": `\circ`, "∗": "*", "−": "-",
+	"≤": `\leq`, "≥": `\geq`, "≠": `\neq`, "≈": `\approx`, "≡": `\equiv`, "∼": `\sim`, "≅": `\cong`,
+	"∝": `\propto`, "≪": `\ll`, "≫": `\gg`, "∈": `\in`, "∉": `\notin`, "∋": `\ni`, "⊂": `\subset`,
+	"⊃": `\supset`, "⊆": `\subseteq`, "⊇": `\supseteq`, "∪": `\cup`, "∩": `\cap`, "∖": `\setminus`,
+	"∂": `\partial`, "∇": `\nabla`, "∀": `\forall`, "∃": `\exists`, "¬": `\neg`, "∧": `\land`,
+	"∨": `\lor`, "→": `\to`, "←": `\leftarrow`, "↔": `\leftrightarrow`, "⇒": `\Rightarrow`,
+	"⇐": `\Leftarrow`, "⇔": `\Leftrightarrow`, "↦": `\mapsto`, "…": `\ldots`, "⋯": `\cdots`,
+	"⋮": `\vdots`, "⋱": `\ddots`, "∣": `\mid`, "‖": `\|`, "′": "'", "″": "''", "⟨": `\langle`,
+	"⟩": `\rangle`, "{": `\{`, "}": `\}`, "⌊": `\lfloor`, "⌋": `\rfloor`, "⌈": `\lceil`, "⌉": `\rceil`,
+	"\u2061": "", "\u2062": "", "\u2063": ",", "\u2064": "+", // invisible operators
+}
+
+// accents placed over or under a base by <mover> and <munder>.
+var mathMLAccents = map[string]string{
+	"^": `\hat`, "ˆ": `\hat`, "¯": `\overline`, "‾": `\overline`, "―": `\overline`, "→": `\vec`,
+	"\u20d7": `\vec`, "~": `\tilde`, "˜": `\tilde`, "˙": `\dot`, "¨": `\ddot`, "⏞": `\overbrace`,
+	"_": `\underline`, "⏟": `\underbrace`,
+}
+
+// operators whose limits are written as sub and superscripts.
+var mathMLLimitOperators = []string{`\sum`, `\prod`, `\int`, `\lim`, `\max`, `\min`, `\sup`, `\inf`, `\bigcup`, `\bigcap`}
+
+// mathMLToLaTeX performs a best-effort conversion of presentation MathML to LaTeX.
+func mathMLToLaTeX(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return strings.TrimSpace(node.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	children := mathMLChildren(node)
+	arg := func(i int) string {
+		if i >= len(children) {
+			return ""
+		}
+		return mathMLToLaTeX(children[i])
+	}
+
+	switch node.Data {
+	case "mi":
+		text := strings.TrimSpace(textContent(node))
+		if command, ok := mathMLIdentifiers[text]; ok {
+			return command
+		}
+		if itemInSlice(text, mathMLFunctions) {
+			return `\` + text
+		}
+		if utf8.RuneCountInString(text) > 1 {
+			return `\mathrm{` + text + `}`
+		}
+		return text
+
+	case "mn", "ms":
+		return strings.TrimSpace(textContent(node))
+
+	case "mo":
+		text := strings.TrimSpace(textContent(node))
+		if command, ok := mathMLOperators[text]; ok {
+			return command
+		}
+		if itemInSlice(text, mathMLFunctions) {
+			return `\` + text
+		}
+		return text
+
+	case "mtext":
+		text := textContent(node)
+		if strings.TrimSpace(text) == "" {
+			return `\ `
+		}
+		return `\text{` + text + `}`
+
+	case "mspace":
+		return `\ `
+
+	case "msup":
+		return mathMLGroup(arg(0)) + "^" + mathMLGroup(arg(1))
+
+	case "msub":
+		return mathMLGroup(arg(0)) + "_" + mathMLGroup(arg(1))
+
+	case "msubsup":
+		return mathMLGroup(arg(0)) + "_" + mathMLGroup(arg(1)) + "^" + mathMLGroup(arg(2))
+
+	case "mfrac":
+		return `\frac{` + arg(0) + `}{` + arg(1) + `}`
+
+	case "msqrt":
+		return `\sqrt{` + mathMLJoin(children) + `}`
+
+	case "mroot":
+		return `\sqrt[` + arg(1) + `]{` + arg(0) + `}`
+
+	case "mover", "munder":
+		base, script := arg(0), arg(1)
+		if len(children) > 1 {
+			if accent, ok := mathMLAccents[strings.TrimSpace(textContent(children[1]))]; ok {
+				return accent + `{` + base + `}`
+			}
+		}
+		if itemInSlice(base, mathMLLimitOperators) {
+			if node.Data == "mover" {
+				return base + "^" + mathMLGroup(script)
+			}
+			return base + "_" + mathMLGroup(script)
+		}
+		if node.Data == "mover" {
+			return `\overset{` + script + `}{` + base + `}`
+		}
+		return `\underset{` + script + `}{` + base + `}`
+
+	case "munderover":
+		return arg(0) + "_" + mathMLGroup(arg(1)) + "^" + mathMLGroup(arg(2))
+
+	case "mfenced":
+		open, close := "(", ")"
+		if value := findAttribute(node, "open"); value != "" {
+			open = value
+		}
+		if value := findAttribute(node, "close"); value != "" {
+			close = value
+		}
+		separator := findAttribute(node, "separators")
+		if separator == "" {
+			separator = ","
+		}
+		parts := make([]string, 0, len(children))
+		for _, child := range children {
+			parts = append(parts, mathMLToLaTeX(child))
+		}
+		// the first separator is used between all the children
+		first, _ := utf8.DecodeRuneInString(separator)
+		return open + strings.Join(parts, string(first)) + close
+
+	case "mtable":
+		rows := make([]string, 0, len(children))
+		for _, row := range children {
+			cells := []string{}
+			for _, cell := range mathMLChildren(row) {
+				cells = append(cells, mathMLJoin(mathMLChildren(cell)))
+			}
+			rows = append(rows, strings.Join(cells, " & "))
+		}
+		return `\begin{matrix} ` + strings.Join(rows, ` \\ `) + ` \end{matrix}`
+
+	case "semantics":
+		return arg(0)
+
+	case "annotation", "annotation-xml", "mphantom":
+		return ""
+	}
+
+	// math, mrow, mstyle, mpadded, menclose and everything unknown
+	return mathMLJoin(children)
+}
+
+// mathMLChildren returns the element children of a MathML node.
+func mathMLChildren(node *html.Node) []*html.Node {
+	var children []*html.Node
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// mathMLJoin converts the nodes and concatenates them, separating a command
+// from a following letter so that `\alpha x` doesn't become `\alphax`.
+func mathMLJoin(nodes []*html.Node) string {
+	var builder strings.Builder
+	for _, node := range nodes {
+		part := mathMLToLaTeX(node)
+		if part == "" {
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(part)
+		if unicode.IsLetter(first) && texCommandSuffix.MatchString(builder.String()) {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
+
+// mathMLGroup wraps multi-token arguments of sub and superscripts in braces.
+func mathMLGroup(s string) string {
+	if utf8.RuneCountInString(s) == 1 || texCommandSuffix.MatchString(s) && strings.Count(s, `\`) == 1 && strings.HasPrefix(s, `\`) {
+		return s
+	}
+	return "{" + s + "}"
+}
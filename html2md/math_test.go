@@ -0,0 +1,100 @@
+package html2md
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "KaTeX inline",
+			input:    `<p>Area <span class="katex"><span class="katex-mathml"><math><semantics><mrow><mi>π</mi><msup><mi>r</mi><mn>2</mn></msup></mrow><annotation encoding="application/x-tex">\pi r^2</annotation></semantics></math></span><span class="katex-html" aria-hidden="true">πr2</span></span>.</p>`,
+			expected: "Area $\\pi r^2$.\n\n",
+		},
+		{
+			name:     "KaTeX display",
+			input:    `<p>Sum:<span class="katex-display"><span class="katex"><span class="katex-mathml"><math display="block"><semantics><mi>n</mi><annotation encoding="application/x-tex">\sum_{i=1}^n i</annotation></semantics></math></span><span class="katex-html">n</span></span></span></p>`,
+			expected: "Sum:\n$$\n\\sum_{i=1}^n i\n$$\n\n",
+		},
+		{
+			name:     "MathJax v2 scripts",
+			input:    `<p>Inline <span class="MathJax_Preview">a+b</span><span class="MathJax">a+b</span><script type="math/tex">a+b</script>.</p><div class="MathJax_Display"><span class="MathJax">E=mc2</span></div><script type="math/tex; mode=display">E = mc^2</script>`,
+			expected: "Inline $a+b$.\n\n$$\nE = mc^2\n$$\n\n",
+		},
+		{
+			name:     "MathJax v3 assistive MathML",
+			input:    `<p>Root <mjx-container class="MathJax CtxtMenu_Attached_0" jax="CHTML"><mjx-math aria-hidden="true">garbage</mjx-math><mjx-assistive-mml><math><msqrt><mi>x</mi></msqrt></math></mjx-assistive-mml></mjx-container></p>`,
+			expected: "Root $\\sqrt{x}$\n\n",
+		},
+		{
+			name:     "Pandoc math spans",
+			input:    `<p>Let <span class="math inline">\(a_1\)</span> be</p><p><span class="math display">\[a_1 = 0\]</span></p>`,
+			expected: "Let $a_1$ be\n\n$$\na_1 = 0\n$$\n\n",
+		},
+		{
+			name:     "Bare MathML",
+			input:    `<p>Roots: <math><mi>x</mi><mo>=</mo><mfrac><mrow><mo>−</mo><mi>b</mi><mo>±</mo><msqrt><msup><mi>b</mi><mn>2</mn></msup><mo>−</mo><mn>4</mn><mi>a</mi><mi>c</mi></msqrt></mrow><mrow><mn>2</mn><mi>a</mi></mrow></mfrac></math></p>`,
+			expected: "Roots: $x=\\frac{-b\\pm\\sqrt{b^2-4ac}}{2a}$\n\n",
+		},
+		{
+			name:     "Scripts are still ignored",
+			input:    `<p>Text</p><script>alert("hi")</script>`,
+			expected: "Text\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter().ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestMathMLToLaTeX(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`<math><msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup></math>`, `x_i^2`},
+		{`<math><msub><mi>a</mi><mrow><mi>n</mi><mo>+</mo><mn>1</mn></mrow></msub></math>`, `a_{n+1}`},
+		{`<math><mroot><mi>x</mi><mn>3</mn></mroot></math>`, `\sqrt[3]{x}`},
+		{`<math><munderover><mo>∑</mo><mrow><mi>k</mi><mo>=</mo><mn>0</mn></mrow><mi>∞</mi></munderover><msub><mi>a</mi><mi>k</mi></msub></math>`, `\sum_{k=0}^\infty a_k`},
+		{`<math><munder><mo>lim</mo><mrow><mi>x</mi><mo>→</mo><mn>0</mn></mrow></munder><mi>f</mi></math>`, `\lim_{x\to0}f`},
+		{`<math><mover><mi>v</mi><mo>→</mo></mover><mo>⋅</mo><mover><mi>w</mi><mo>^</mo></mover></math>`, `\vec{v}\cdot\hat{w}`},
+		{`<math><mi>sin</mi><mo>⁡</mo><mi>θ</mi><mo>≤</mo><mn>1</mn></math>`, `\sin\theta\leq1`},
+		{`<math><mtext>if </mtext><mi>x</mi></math>`, `\text{if }x`},
+		{`<math><mfenced><mi>a</mi><mi>b</mi></mfenced></math>`, `(a,b)`},
+		{`<math><mfenced separators="·;"><mi>a</mi><mi>b</mi></mfenced></math>`, `(a·b)`},
+		{`<math><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>0</mn></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mn>1</mn></mtd></mtr></mtable></math>`, `\begin{matrix} 1 & 0 \\ 0 & 1 \end{matrix}`},
+	}
+
+	for _, test := range tests {
+		doc, err := html.Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var math *html.Node
+		for node := range doc.Descendants() {
+			if node.Type == html.ElementNode && node.Data == "math" {
+				math = node
+				break
+			}
+		}
+		if got := mathMLToLaTeX(math); got != test.expected {
+			t.Errorf("expected `%v`, got `%v`", test.expected, got)
+		}
+	}
+}
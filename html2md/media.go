@@ -0,0 +1,158 @@
+package html2md
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// embedProvider recognizes the embed urls of a site. It returns the url of the
+// page holding the media, and a thumbnail when the site provides one.
+type embedProvider struct {
+	name    string
+	pattern *regexp.Regexp
+	page    func(match []string) string
+	thumb   func(match []string) string
+}
+
+var embedProviders = []embedProvider{
+	{
+		name:    "YouTube video",
+		pattern: regexp.MustCompile(`^(?:https?:)?//(?:www\.)?youtube(?:-nocookie)?\.com/embed/([\w-]+)`),
+		page:    func(m []string) string { return "https://www.youtube.com/watch?v=" + m[1] },
+		thumb:   func(m []string) string { return "https://img.youtube.com/vi/" + m[1] + "/hqdefault.jpg" },
+	},
+	{
+		name:    "Vimeo video",
+		pattern: regexp.MustCompile(`^(?:https?:)?//player\.vimeo\.com/video/(\d+)`),
+		page:    func(m []string) string { return "https://vimeo.com/" + m[1] },
+	},
+	{
+		name:    "CodePen",
+		pattern: regexp.MustCompile(`^(?:https?:)?//codepen\.io/([\w-]+)/embed/(?:preview/)?(\w+)`),
+		page:    func(m []string) string { return "https://codepen.io/" + m[1] + "/pen/" + m[2] },
+	},
+	{
+		name:    "GitHub Gist",
+		pattern: regexp.MustCompile(`^(?:https?:)?//gist\.github\.com/([\w-]+/\w+)(?:\.js|\.pibb)?`),
+		page:    func(m []string) string { return "https://gist.github.com/" + m[1] },
+	},
+}
+
+// isEmbedScript reports whether the node is a script embedding media from a
+// known provider, like `<script src="https://gist.github.com/user/id.js">`.
+func isEmbedScript(node *html.Node) bool {
+	if node.Data != "script" {
+		return false
+	}
+	src := findAttribute(node, "src")
+	for _, provider := range embedProviders {
+		if provider.pattern.MatchString(src) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaSource returns the url of a video or audio element, from its src
+// attribute or its first <source>.
+func mediaSource(node *html.Node) string {
+	if src := findAttribute(node, "src"); src != "" {
+		return src
+	}
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "source" {
+			if src := findAttribute(child, "src"); src != "" {
+				return src
+			}
+		}
+	}
+	return ""
+}
+
+// renderHTML returns the HTML source of the node.
+func renderHTML(node *html.Node) string {
+	var buffer bytes.Buffer
+	if err := html.Render(&buffer, node); err != nil {
+		return ""
+	}
+	return buffer.String()
+}
+
+// sanitizedMedia returns a copy of the media node without its scripts, event
+// handlers, inline documents and URLs able to run scripts.
+func sanitizedMedia(node *html.Node) *html.Node {
+	clone := &html.Node{Type: node.Type, DataAtom: node.DataAtom, Data: node.Data, Namespace: node.Namespace}
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if strings.HasPrefix(key, "on") || key == "srcdoc" || itemInSlice(key, urlAttributes) && isUnsafeURL(attr.Val) {
+			continue
+		}
+		clone.Attr = append(clone.Attr, attr)
+	}
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode && child.Data == "script" {
+			continue
+		}
+		clone.AppendChild(sanitizedMedia(child))
+	}
+	return clone
+}
+
+// findMedia returns the element for video, audio, iframe, embed and embed
+// script nodes, or nil for any other node.
+func (c *Converter) findMedia(node *html.Node) *MediaTag {
+	var kind, src, thumbnail string
+	switch node.Data {
+	case "video":
+		kind, src, thumbnail = "Video", mediaSource(node), findAttribute(node, "poster")
+	case "audio":
+		kind, src = "Audio", mediaSource(node)
+	case "iframe", "embed":
+		kind, src = "Embedded content", findAttribute(node, "src")
+	case "script":
+		if !isEmbedScript(node) {
+			return nil
+		}
+		kind, src = "Embedded content", findAttribute(node, "src")
+	default:
+		return nil
+	}
+	if src == "" {
+		return nil
+	}
+
+	title := findAttribute(node, "title")
+	if title == "" {
+		title = findAttribute(node, "aria-label")
+	}
+
+	for _, provider := range embedProviders {
+		match := provider.pattern.FindStringSubmatch(src)
+		if match == nil {
+			continue
+		}
+		src, kind = provider.page(match), provider.name
+		if provider.thumb != nil {
+			thumbnail = provider.thumb(match)
+		}
+		break
+	}
+
+	if title == "" {
+		title = kind
+	}
+
+	style, source := c.options.mediaStyle, ""
+	if style == MediaHTML {
+		if node.Data == "script" {
+			// the scripts are never passed through
+			style = MediaLink
+		} else {
+			source = renderHTML(sanitizedMedia(node))
+		}
+	}
+	return NewMediaTag(c.rewriteURL(src), title, c.rewriteURL(thumbnail), source, style)
+}
@@ -0,0 +1,85 @@
+package html2md
+
+import "testing"
+
+func TestMedia(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    MediaStyle
+		input    string
+		expected string
+	}{
+		{
+			name:     "Video link",
+			style:    MediaLink,
+			input:    `<video src="/clip.mp4" poster="/poster.jpg" controls>Your browser does not support video.</video>`,
+			expected: "[Video](/clip.mp4)\n",
+		},
+		{
+			name:     "Video with poster thumbnail",
+			style:    MediaThumbnail,
+			input:    `<video controls poster="/poster.jpg" title="Demo"><source src="/clip.webm" type="video/webm"><source src="/clip.mp4"></video>`,
+			expected: "[![Demo](/poster.jpg)](/clip.webm)\n",
+		},
+		{
+			name:     "Audio without thumbnail",
+			style:    MediaThumbnail,
+			input:    `<audio controls><source src="/song.ogg" type="audio/ogg"></audio>`,
+			expected: "[Audio](/song.ogg)\n",
+		},
+		{
+			name:     "YouTube embed",
+			style:    MediaLink,
+			input:    `<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ?start=10" title="Launch talk"></iframe>`,
+			expected: "[Launch talk](https://www.youtube.com/watch?v=dQw4w9WgXcQ)\n",
+		},
+		{
+			name:     "YouTube embed thumbnail",
+			style:    MediaThumbnail,
+			input:    `<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"></iframe>`,
+			expected: "[![YouTube video](https://img.youtube.com/vi/dQw4w9WgXcQ/hqdefault.jpg)](https://www.youtube.com/watch?v=dQw4w9WgXcQ)\n",
+		},
+		{
+			name:     "Vimeo, CodePen and Gist embeds",
+			style:    MediaThumbnail,
+			input:    `<iframe src="https://player.vimeo.com/video/76979871"></iframe><iframe src="https://codepen.io/team/embed/preview/abCDe"></iframe><script src="https://gist.github.com/octocat/6cad326836d38bd3a7ae.js"></script>`,
+			expected: "[Vimeo video](https://vimeo.com/76979871)\n[CodePen](https://codepen.io/team/pen/abCDe)\n[GitHub Gist](https://gist.github.com/octocat/6cad326836d38bd3a7ae)\n",
+		},
+		{
+			name:     "Unknown iframe and embed",
+			style:    MediaLink,
+			input:    `<iframe src="https://example.com/widget"></iframe><embed src="/movie.swf">`,
+			expected: "[Embedded content](https://example.com/widget)\n[Embedded content](/movie.swf)\n",
+		},
+		{
+			name:     "Raw HTML passthrough",
+			style:    MediaHTML,
+			input:    `<video src="/clip.mp4" controls></video>`,
+			expected: "<video src=\"/clip.mp4\" controls=\"\"></video>\n",
+		},
+		{
+			name:     "html without scripts",
+			style:    MediaHTML,
+			input:    `<video src="javascript:alert(1)" poster=" javascript:alert(2)" onerror="alert(3)" controls><source src="/clip.webm" onError="alert(4)"><script>alert(5)</script></video>`,
+			expected: "<video controls=\"\"><source src=\"/clip.webm\"/></video>\n",
+		},
+		{
+			name:     "html embed script",
+			style:    MediaHTML,
+			input:    `<p>Code</p><script src="https://gist.github.com/user/abc123.js"></script>`,
+			expected: "Code\n\n[GitHub Gist](https://gist.github.com/user/abc123)\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithMediaStyle(test.style)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
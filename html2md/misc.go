@@ -1,6 +1,11 @@
 package html2md
 
-import "iter"
+import (
+	"iter"
+	"strings"
+
+	"golang.org/x/net/html"
+)
 
 // collapseWhitespace reduces runs of HTML whitespace (space, tab, newline,
 // carriage return, form feed) to a single space, except runs containing two or
@@ -65,3 +70,17 @@ func itemInSlice[T comparable](item T, slice []T) bool {
 	}
 	return false
 }
+
+// styleProperty returns the value of a property in the inline style of the
+// node, or an empty string when it is not set.
+func styleProperty(node *html.Node, property string) string {
+	value := ""
+	for _, declaration := range strings.Split(findAttribute(node, "style"), ";") {
+		name, val, ok := strings.Cut(declaration, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), property) {
+			// later declarations override earlier ones
+			value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(val), "!important"))
+		}
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,494 @@
+package html2md
+
+import (
+	"maps"
+	"strings"
+)
+
+// Flavor is the markdown dialect written by the converter. It selects the
+// syntax of the elements which are not part of CommonMark, like tables,
+// strikethrough, footnotes, task lists, definition lists, math and callouts,
+// and falls back to HTML or to plain markdown when the flavor has none.
+type Flavor uint
+
+const (
+	// GFM is GitHub Flavored Markdown, the default flavor: pipe tables,
+	// `~~` strikethrough, `[^1]` footnotes, task lists, `$` math and alerts.
+	GFM Flavor = iota
+	// Obsidian is the markdown understood by the Obsidian editor: GFM with
+	// callouts, tags, wikilinks, highlights and comments.
+	Obsidian
+	// Pandoc is Pandoc's extended markdown: GFM with definition lists,
+	// `{#id}` attributes, fenced divs and backslash hard line breaks.
+	Pandoc
+	// CommonMark has no extensions: tables are written as HTML,
+	// strikethrough as `<del>`, footnotes as numbered links, math as code and
+	// hard line breaks with a backslash.
+	CommonMark
+	// MultiMarkdown has pipe tables, `[^1]` footnotes, definition lists, `$`
+	// math and `[id]` heading attributes, but neither strikethrough nor task
+	// lists. Underscores inside words are escaped as they start emphasis.
+	MultiMarkdown
+)
+
+// feature is a markdown syntax missing from CommonMark.
+type feature uint
+
+const (
+	tablesFeature feature = iota
+	strikethroughFeature
+	taskListsFeature
+	definitionListsFeature
+	footnotesFeature
+	mathFeature
+)
+
+// supports reports whether the flavor has the syntax of the feature.
+func (f Flavor) supports(feat feature) bool {
+	switch feat {
+	case tablesFeature, footnotesFeature, mathFeature:
+		return f != CommonMark
+	case strikethroughFeature, taskListsFeature:
+		return f == GFM || f == Obsidian || f == Pandoc
+	case definitionListsFeature:
+		return f == Pandoc || f == MultiMarkdown
+	default:
+		return false
+	}
+}
+
+// CaptionStyle is how the caption of a figure is written.
+type CaptionStyle uint
+
+const (
+	// CaptionItalic writes the caption as an italic paragraph after the figure.
+	CaptionItalic CaptionStyle = iota
+	// CaptionTitle writes the caption as the title of the image.
+	CaptionTitle
+)
+
+// MediaStyle is how video, audio and embedded content is written.
+type MediaStyle uint
+
+const (
+	// MediaLink writes a link to the media, or to its page for known
+	// providers like YouTube, Vimeo, CodePen and GitHub Gist.
+	MediaLink MediaStyle = iota
+	// MediaThumbnail writes the link around the poster image of a video or the
+	// thumbnail of a known provider, and falls back to MediaLink without one.
+	MediaThumbnail
+	// MediaHTML passes the element through as raw HTML, without its scripts,
+	// event handlers and script URLs. The embed scripts are written as links.
+	MediaHTML
+)
+
+// ListIndent is how the nested lines of a list item are indented.
+type ListIndent int
+
+const (
+	// IndentTab indents with a tab for every level of nesting, the default.
+	IndentTab ListIndent = 0
+	// IndentMarker aligns the nested lines with the content after the marker
+	// of the item, e.g. 2 spaces after `- ` and 3 spaces after `1. `.
+	IndentMarker ListIndent = -1
+)
+
+// IndentSpaces indents with n spaces for every level of nesting, n is at least 1.
+func IndentSpaces(n int) ListIndent {
+	return ListIndent(max(n, 1))
+}
+
+// ListNumbering is how the items of ordered lists are numbered.
+type ListNumbering uint
+
+const (
+	// NumberSequential numbers the items one after the other, the default.
+	NumberSequential ListNumbering = iota
+	// NumberRepeat repeats the number of the first item on every item, like
+	// `1.` for all of them. Markdown renderers still number them sequentially,
+	// and inserting an item does not change the following lines.
+	NumberRepeat
+)
+
+// EscapeMode is how the characters of the text which could be read as markdown
+// syntax are escaped.
+type EscapeMode uint
+
+const (
+	// EscapeMinimal escapes only the characters which would start markdown
+	// syntax at their position, like `#` at the start of a line or `*`
+	// next to a word. This is the default.
+	EscapeMinimal EscapeMode = iota
+	// EscapeAggressive escapes the markdown punctuation characters
+	// `\ * _ { } [ ] ( ) # + - !` wherever they are.
+	EscapeAggressive
+)
+
+// HeadingStyle is the syntax of headings.
+type HeadingStyle uint
+
+const (
+	// HeadingATX writes headings after `#` markers, the default.
+	HeadingATX HeadingStyle = iota
+	// HeadingSetext underlines level 1 and 2 headings with `=` and `-`, the
+	// other levels are written as ATX headings.
+	HeadingSetext
+)
+
+// HeadingIDStyle is how the ids of headings and anchors are written.
+type HeadingIDStyle uint
+
+const (
+	// HeadingIDNone drops the ids, the default.
+	HeadingIDNone HeadingIDStyle = iota
+	// HeadingIDSlug drops the ids of headings, and points the in-page links to
+	// them to the slugs GitHub generates from the heading text.
+	HeadingIDSlug
+	// HeadingIDAttribute writes the ids as attributes, like
+	// `## Heading {#id}`, as understood by Pandoc and others, or
+	// `## Heading [id]` in MultiMarkdown.
+	HeadingIDAttribute
+	// HeadingIDAnchor writes the ids as HTML anchors, like
+	// `## <a id="id"></a>Heading`.
+	HeadingIDAnchor
+)
+
+// UnknownElementPolicy is how the elements without a markdown equivalent, like
+// `<abbr>`, `<sup>` or `<u>`, are written.
+type UnknownElementPolicy uint
+
+const (
+	// UnknownStrip drops the tags and keeps their content, the default.
+	UnknownStrip UnknownElementPolicy = iota
+	// UnknownPassthrough writes the elements of the allowed tags as sanitized
+	// raw HTML, with only the allowed attributes. Their content is still
+	// converted to markdown. The other elements are stripped.
+	UnknownPassthrough
+	// UnknownDrop drops the elements together with their content. Layout
+	// elements like `<div>`, `<span>` or `<section>` are stripped instead.
+	UnknownDrop
+)
+
+// LineBreakStyle is how the hard line breaks of `<br>` are written.
+type LineBreakStyle uint
+
+const (
+	// BreakDefault uses the style of the flavor: a backslash in CommonMark and
+	// Pandoc, trailing spaces otherwise.
+	BreakDefault LineBreakStyle = iota
+	// BreakSpaces ends the line with two spaces, which are invisible and
+	// often stripped by editors.
+	BreakSpaces
+	// BreakBackslash ends the line with a backslash.
+	BreakBackslash
+	// BreakHTML writes a `<br>` tag.
+	BreakHTML
+)
+
+// DetailsStyle is how the collapsible `<details>` sections are written.
+type DetailsStyle uint
+
+const (
+	// DetailsHTML keeps the `<details>` and `<summary>` tags around the
+	// markdown body, separated from it by blank lines. This is the default.
+	DetailsHTML DetailsStyle = iota
+	// DetailsCallout writes a foldable Obsidian callout, like `> [!faq]- Summary`,
+	// which is expanded when the section is open.
+	DetailsCallout
+	// DetailsHeading writes the summary as a heading one level below the
+	// section holding it, followed by the body.
+	DetailsHeading
+)
+
+// Preset cleans up the HTML exported by an application before the conversion.
+type Preset uint
+
+const (
+	// PresetNone converts the HTML as it is.
+	PresetNone Preset = iota
+	// PresetWord cleans up the HTML of Microsoft Word and Outlook: the
+	// paragraphs with an `mso-list` style become nested lists, and the Office
+	// elements like `<o:p>`, the content of conditional comments and the
+	// blank spacing paragraphs are removed.
+	PresetWord
+	// PresetGoogleDocs cleans up the HTML exported by Google Docs: the styles
+	// of the classes in its `<style>` element are read like inline styles,
+	// the links through the Google redirect are replaced by their destination
+	// and the nesting of the lists is rebuilt from their `lst-kix` classes.
+	PresetGoogleDocs
+	// PresetConfluence reads the storage format of Confluence: the code macro
+	// is a fenced code block, the info, note, warning and tip macros are
+	// callouts, the expand macro is a collapsible section, and the
+	// attachments and the links to pages are relative paths.
+	PresetConfluence
+)
+
+type options struct {
+	flavor        Flavor
+	figureCaption CaptionStyle
+	imageWidth    int
+	mediaStyle    MediaStyle
+	counterStyles map[string]CounterStyle
+	listIndent    ListIndent
+	bullets       []string
+	listNumbering ListNumbering
+	escapeMode    EscapeMode
+	headingStyle  HeadingStyle
+	closeHeadings bool
+	headingOffset int
+	// shift the headings so that the top level of the document is 1
+	normalizeHeadings bool
+	headingIDs        HeadingIDStyle
+	tableOfContents   bool
+	wrapWidth         int
+	sentenceBreaks    bool
+	abbreviations     []string
+	unknownElements   UnknownElementPolicy
+	allowedTags       []string
+	allowedAttributes []string
+	detailsStyle      DetailsStyle
+	lineBreakStyle    LineBreakStyle
+	preset            Preset
+	urlRewriter       func(url string) string
+}
+
+func defaultOptions() options {
+	return options{
+		flavor:        GFM,
+		figureCaption: CaptionItalic,
+		imageWidth:    0,
+		mediaStyle:    MediaLink,
+		counterStyles: maps.Clone(counterStyles),
+		listIndent:    IndentTab,
+		bullets:       []string{"-"},
+		listNumbering: NumberSequential,
+		escapeMode:    EscapeMinimal,
+		headingStyle:  HeadingATX,
+		allowedTags: []string{
+			"abbr", "bdi", "bdo", "cite", "details", "dfn", "ins", "kbd", "mark", "q",
+			"rp", "rt", "ruby", "s", "samp", "small", "sub", "summary", "sup", "time",
+			"u", "var", "wbr",
+		},
+		allowedAttributes: []string{"title", "open", "lang", "dir", "datetime", "cite"},
+	}
+}
+
+// Option configures a Converter, see NewConverter.
+type Option func(*options)
+
+// WithFlavor sets the markdown flavor of the output.
+func WithFlavor(flavor Flavor) Option {
+	return func(o *options) {
+		o.flavor = flavor
+	}
+}
+
+// WithFigureCaption sets how the <figcaption> of a figure is written.
+func WithFigureCaption(style CaptionStyle) Option {
+	return func(o *options) {
+		o.figureCaption = style
+	}
+}
+
+// WithImageWidth sets the preferred width in pixels of images with a srcset,
+// the smallest candidate at least this wide is used. By default the
+// highest-resolution candidate is used.
+func WithImageWidth(width int) Option {
+	return func(o *options) {
+		o.imageWidth = width
+	}
+}
+
+// WithMediaStyle sets how <video>, <audio>, <iframe> and <embed> elements are written.
+func WithMediaStyle(style MediaStyle) Option {
+	return func(o *options) {
+		o.mediaStyle = style
+	}
+}
+
+// WithCounterStyle registers a counter style for ordered lists, used for the
+// lists whose CSS `list-style-type` has the given name. It replaces the
+// built-in style of the same name, if any.
+func WithCounterStyle(name string, style CounterStyle) Option {
+	return func(o *options) {
+		o.counterStyles[strings.ToLower(name)] = style
+	}
+}
+
+// WithListIndent sets the indentation of nested lists and of the block content
+// of list items.
+func WithListIndent(indent ListIndent) Option {
+	return func(o *options) {
+		o.listIndent = indent
+	}
+}
+
+// WithBullets sets the markers of unordered list items, which are one of `-`,
+// `*` and `+`. Nested lists rotate through them by depth, e.g.
+// `WithBullets("-", "*", "+")`. Other markers are ignored.
+func WithBullets(bullets ...string) Option {
+	return func(o *options) {
+		var valid []string
+		for _, bullet := range bullets {
+			if itemInSlice(bullet, []string{"-", "*", "+"}) {
+				valid = append(valid, bullet)
+			}
+		}
+		if len(valid) > 0 {
+			o.bullets = valid
+		}
+	}
+}
+
+// WithListNumbering sets how the items of ordered lists are numbered.
+func WithListNumbering(numbering ListNumbering) Option {
+	return func(o *options) {
+		o.listNumbering = numbering
+	}
+}
+
+// WithEscapeMode sets how the characters of the text which could be read as
+// markdown syntax are escaped.
+func WithEscapeMode(mode EscapeMode) Option {
+	return func(o *options) {
+		o.escapeMode = mode
+	}
+}
+
+// WithHeadingStyle sets the syntax of headings.
+func WithHeadingStyle(style HeadingStyle) Option {
+	return func(o *options) {
+		o.headingStyle = style
+	}
+}
+
+// WithClosingSequence closes ATX headings with as many `#` as they start with,
+// like `## Heading ##`.
+func WithClosingSequence(closed bool) Option {
+	return func(o *options) {
+		o.closeHeadings = closed
+	}
+}
+
+// WithHeadingOffset demotes every heading by offset levels, or promotes them
+// when it is negative, which helps to embed a page under an existing section.
+// Levels are clamped between 1 and 6.
+func WithHeadingOffset(offset int) Option {
+	return func(o *options) {
+		o.headingOffset = offset
+	}
+}
+
+// WithNormalizedHeadings shifts the headings of the document so that its top
+// level is 1, before applying the heading offset.
+func WithNormalizedHeadings(normalize bool) Option {
+	return func(o *options) {
+		o.normalizeHeadings = normalize
+	}
+}
+
+// WithHeadingIDs sets how the ids of headings and of anchors like
+// `<a id="x">` are written, and rewrites the in-page links to them.
+func WithHeadingIDs(style HeadingIDStyle) Option {
+	return func(o *options) {
+		o.headingIDs = style
+	}
+}
+
+// WithTableOfContents writes a list linking to the headings of the document
+// where the input has a `<!-- toc -->` comment, or at the top otherwise.
+func WithTableOfContents(enabled bool) Option {
+	return func(o *options) {
+		o.tableOfContents = enabled
+	}
+}
+
+// WithWrap breaks the lines of paragraphs longer than width columns between
+// words, including the prefixes of their containers. Link destinations and
+// code are never broken. A width of 0, the default, disables wrapping.
+func WithWrap(width int) Option {
+	return func(o *options) {
+		o.wrapWidth = width
+	}
+}
+
+// defaultAbbreviations are the abbreviations which don't end a sentence.
+var defaultAbbreviations = []string{
+	"e.g.", "i.e.", "etc.", "vs.", "cf.", "approx.", "al.", "fig.", "no.",
+	"Dr.", "Mr.", "Mrs.", "Ms.", "Prof.", "Sr.", "Jr.", "St.", "Inc.", "Ltd.",
+}
+
+// WithSemanticLineBreaks starts every sentence of a paragraph on a new line,
+// so that editing a sentence changes a single line of the output. A period
+// ending an abbreviation (see WithAbbreviations) or an initial doesn't end a
+// sentence. It can be combined with WithWrap.
+func WithSemanticLineBreaks(enabled bool) Option {
+	return func(o *options) {
+		o.sentenceBreaks = enabled
+	}
+}
+
+// WithAbbreviations adds abbreviations, like `Fig.`, which don't end a sentence
+// for WithSemanticLineBreaks. The comparison ignores case.
+func WithAbbreviations(abbreviations ...string) Option {
+	return func(o *options) {
+		o.abbreviations = append(o.abbreviations, abbreviations...)
+	}
+}
+
+// WithUnknownElements sets how the elements without a markdown equivalent are
+// written, see UnknownElementPolicy.
+func WithUnknownElements(policy UnknownElementPolicy) Option {
+	return func(o *options) {
+		o.unknownElements = policy
+	}
+}
+
+// WithAllowedTags replaces the tags passed through as raw HTML by
+// UnknownPassthrough. Tags which can run scripts or load content, like
+// `<script>`, `<style>` or `<object>`, are never passed through.
+func WithAllowedTags(tags ...string) Option {
+	return func(o *options) {
+		o.allowedTags = tags
+	}
+}
+
+// WithAllowedAttributes replaces the attributes kept on the elements passed
+// through as raw HTML. Event handlers like `onclick` are never kept, and
+// neither are URLs with a `javascript:`, `vbscript:` or `data:` scheme.
+func WithAllowedAttributes(attributes ...string) Option {
+	return func(o *options) {
+		o.allowedAttributes = attributes
+	}
+}
+
+// WithDetailsStyle sets how the collapsible `<details>` sections are written.
+func WithDetailsStyle(style DetailsStyle) Option {
+	return func(o *options) {
+		o.detailsStyle = style
+	}
+}
+
+// WithLineBreakStyle sets how the hard line breaks of `<br>` are written.
+// Consecutive breaks always end the paragraph, breaks in headings are written
+// as spaces and breaks in table cells as `<br>`.
+func WithLineBreakStyle(style LineBreakStyle) Option {
+	return func(o *options) {
+		o.lineBreakStyle = style
+	}
+}
+
+// WithPreset cleans up the HTML exported by an application before the conversion.
+func WithPreset(preset Preset) Option {
+	return func(o *options) {
+		o.preset = preset
+	}
+}
+
+// WithURLRewriter replaces the URLs of links, images and media by the result
+// of rewrite, e.g. to point them to the files written next to the output.
+func WithURLRewriter(rewrite func(url string) string) Option {
+	return func(o *options) {
+		o.urlRewriter = rewrite
+	}
+}
@@ -1,45 +1,141 @@
 package html2md
 
 import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-// outputWriter is a wrapper around strings.Builder.
+// containerKind is the kind of block holding other blocks.
+type containerKind uint
+
+const (
+	blockquoteContainer containerKind = iota
+	listItemContainer
+	footnoteContainer
+	calloutContainer
+	definitionContainer
+)
+
+// container is a block holding other blocks, like a blockquote or a list item.
+// Each line of its content, after the one it starts on, begins with its prefix.
+type container struct {
+	kind   containerKind
+	prefix string
+}
+
+// quoted reports whether the prefix of the container holds a blockquote marker,
+// which must also be written on blank lines to keep the container open.
+func (c container) quoted() bool {
+	return strings.Contains(c.prefix, ">")
+}
+
+// outputWriter is a wrapper around bytes.Buffer.
 // It ensures no more than 2 consecutive trailing newlines are written, even across multiple writes.
+// Lines are prefixed by the markers and the indentation of the open containers.
+// When wrapWidth is set, lines longer than it are broken at the spaces of prose text.
+// When sentenceBreaks is set, every sentence of prose text starts a new line.
 type outputWriter struct {
-	writer           *strings.Builder
+	writer           *bytes.Buffer
 	trailingNewlines int
-	blockquoteCount  int
+	containers       *stack[container]
 	insideAnchor     bool // this is not a count because nested anchors are invalid in html
 	hasLastByte      bool
 	lastByte         byte
+	// trailing newlines are held back until the next byte is written, so
+	// that a container ending right after them doesn't prefix the next line.
+	// pendingDepth is the number of containers prefixing those lines.
+	pendingNewlines int
+	pendingDepth    int
+	lineStart       int // index of the current line in writer
+	wrapWidth       int
+	// indexes in writer of the spaces of the current line where it can be broken
+	breaks []int
+	// one sentence per line, sentenceEnd is the index of the space after the
+	// last sentence, or -1
+	sentenceBreaks bool
+	abbreviations  map[string]bool
+	sentenceEnd    int
 }
 
 // newOutputWriter creates a new instance of outputWriter.
 func newOutputWriter() *outputWriter {
-	writer := new(strings.Builder)
+	writer := new(bytes.Buffer)
 	return &outputWriter{
 		writer:           writer,
 		trailingNewlines: 0,
-		blockquoteCount:  0,
+		containers:       newStack[container](),
 		insideAnchor:     false,
 		hasLastByte:      false,
+		sentenceEnd:      -1,
 	}
 }
 
-func (w *outputWriter) addBlockquote() {
-	w.blockquoteCount++
+// pushContainer prefixes the lines written after the current one, until the
+// container is popped.
+func (w *outputWriter) pushContainer(kind containerKind, prefix string) {
+	w.containers.push(container{kind: kind, prefix: prefix})
 }
 
-func (w *outputWriter) removeBlockquote() {
-	if w.blockquoteCount == 0 {
-		panic("remove blockquote called with 0 blockquoteCount")
+func (w *outputWriter) popContainer(kind containerKind) {
+	top, err := w.containers.pop()
+	if err != nil {
+		panic("pop container called with no open containers")
+	}
+	if top.kind != kind {
+		panic(fmt.Sprintf("pop container called for kind %v, but the open container is of kind %v", kind, top.kind))
 	}
-	w.blockquoteCount--
+	// the lines after the container must not be prefixed by it
+	w.pendingDepth = min(w.pendingDepth, w.containers.size())
+}
+
+// linePrefix returns the prefix of a line inside the first depth containers.
+// Blank lines are prefixed only up to the innermost blockquote marker.
+func (w *outputWriter) linePrefix(depth int, blank bool) string {
+	containers := w.containers.elems[:depth]
+	if blank {
+		for len(containers) > 0 && !containers[len(containers)-1].quoted() {
+			containers = containers[:len(containers)-1]
+		}
+	}
+	var builder strings.Builder
+	for _, c := range containers {
+		builder.WriteString(c.prefix)
+	}
+	return builder.String()
+}
+
+// blockMarkersRegex matches a line holding only container markers and
+// indentation, where the next character starts a new block.
+var blockMarkersRegex = regexp.MustCompile(`^[ \t>]*(?:(?:[-+*]|\d{1,9}[.)]|#{1,6}|\[\^[^\]]*\]:)[ \t]+[ \t>]*)*$`)
+
+// atBlockStart reports whether the next byte written starts a new block, which
+// is the case at the beginning of a line or after the markers of containers.
+func (w *outputWriter) atBlockStart() bool {
+	if w.pendingNewlines > 0 {
+		return true
+	}
+	return blockMarkersRegex.Match(w.writer.Bytes()[w.lineStart:])
+}
+
+// lastRune returns the last character written, or 0 when nothing was written.
+func (w *outputWriter) lastRune() rune {
+	if w.pendingNewlines > 0 {
+		return '\n'
+	}
+	r, _ := utf8.DecodeLastRune(w.writer.Bytes())
+	if r == utf8.RuneError {
+		return 0
+	}
+	return r
 }
 
 func (w *outputWriter) isEmpty() bool {
-	return w.writer.Len() == 0
+	return w.writer.Len() == 0 && w.pendingNewlines == 0
 }
 
 func (w *outputWriter) endsWithWhitespace() bool {
@@ -112,23 +208,197 @@ func (w *outputWriter) WriteString(s string) (int, error) {
 		w.trailingNewlines += trailingNewlines
 	}
 
-	if w.insideAnchor && s == "  \n" {
-		s = strings.ReplaceAll(s, "\n", "\n\\")
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			if w.pendingNewlines == 0 {
+				w.pendingDepth = w.containers.size()
+			}
+			w.pendingNewlines++
+			continue
+		}
+		w.flushNewlines(&builder)
+		builder.WriteByte(s[i])
 	}
 
-	if w.blockquoteCount > 0 {
-		s = strings.ReplaceAll(s, "\n", "\n"+strings.Repeat("> ", w.blockquoteCount))
+	if i := strings.LastIndexByte(builder.String(), '\n'); i >= 0 {
+		w.lineStart = w.writer.Len() + i + 1
+		w.breaks = w.breaks[:0]
+		w.sentenceEnd = -1
 	}
-
-	n, err := w.writer.WriteString(s)
+	n, err := w.writer.WriteString(builder.String())
 	if len(s) > 0 {
 		w.hasLastByte = true
 		w.lastByte = s[len(s)-1]
 	}
+	if w.wrapWidth > 0 {
+		w.wrap()
+	}
 	return n, err
 }
 
+// flushNewlines writes the held back newlines, each followed by the line prefix.
+func (w *outputWriter) flushNewlines(builder *strings.Builder) {
+	for i := range w.pendingNewlines {
+		builder.WriteByte('\n')
+		builder.WriteString(w.linePrefix(w.pendingDepth, i < w.pendingNewlines-1))
+	}
+	w.pendingNewlines = 0
+}
+
+// writeProse writes text whose spaces can be turned into line breaks, unlike
+// the syntax of markdown elements, link destinations and code.
+func (w *outputWriter) writeProse(s string) {
+	if w.wrapWidth <= 0 && !w.sentenceBreaks {
+		w.WriteString(s)
+		return
+	}
+	for len(s) > 0 {
+		word, rest, found := strings.Cut(s, " ")
+		w.WriteString(word)
+		if word != "" {
+			w.breakSentence()
+		}
+		if !found {
+			break
+		}
+		if w.pendingNewlines == 0 && w.writer.Len() > w.lineStart {
+			w.breaks = append(w.breaks, w.writer.Len())
+			if w.sentenceBreaks && endsSentence(w.lastWord(), w.abbreviations) {
+				w.sentenceEnd = w.writer.Len()
+			}
+		}
+		w.WriteString(" ")
+		s = rest
+	}
+}
+
+// lastWord returns the text of the current line after its last space.
+func (w *outputWriter) lastWord() string {
+	line := w.writer.Bytes()[w.lineStart:]
+	return string(line[bytes.LastIndexByte(line, ' ')+1:])
+}
+
+// letterAbbreviationRegex matches the abbreviations of single letters followed
+// by periods, like `U.S.` or `a.m.`.
+var letterAbbreviationRegex = regexp.MustCompile(`^(?:\pL\.){2,}$`)
+
+// endsSentence reports whether the word ends a sentence: it ends with `.`, `!`
+// or `?`, before any closing quote, bracket or emphasis, and it is neither an
+// abbreviation, like `etc.` or `U.S.`, nor an initial like `J.`.
+func endsSentence(word string, abbreviations map[string]bool) bool {
+	word = strings.TrimRight(word, `*_)]"'\’”`)
+	if !strings.HasSuffix(word, ".") {
+		return strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+	}
+	word = strings.TrimLeft(word, `*_(["'‘“`)
+	if abbreviations[strings.ToLower(word)] || letterAbbreviationRegex.MatchString(word) {
+		return false
+	}
+	return utf8.RuneCountInString(word) > 2 || !unicode.IsLetter([]rune(word)[0])
+}
+
+// breakSentence starts a new line after the end of the last sentence, unless
+// the next sentence would start a block.
+func (w *outputWriter) breakSentence() {
+	if w.sentenceEnd < 0 {
+		return
+	}
+	for i, pos := range w.breaks {
+		next := w.writer.Bytes()[pos+1:]
+		if pos == w.sentenceEnd && len(next) > 0 && !startsBlockRegex.Match(next) {
+			w.breakLine(i)
+			break
+		}
+	}
+	w.sentenceEnd = -1
+}
+
+// startsBlockRegex matches the text which would start a block, or end a
+// paragraph, at the beginning of a line.
+var startsBlockRegex = regexp.MustCompile(`^(?:[-+*][ \t]|[-+*=_]+[ \t]*$|#|>|\d{1,9}[.)](?:[ \t]|$)|[~\x60]{3}|<|\[ ?[xX ]?\]|\|)`)
+
+// columns returns the width of the text, with tabs up to the next tab stop.
+func columns(text string) int {
+	width := 0
+	for _, r := range text {
+		if r == '\t' {
+			width += 4 - width%4
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// wrap breaks the current line at its last break opportunity within the wrap
+// width, or at the first one when the line has no opportunity within it.
+func (w *outputWriter) wrap() {
+	for {
+		line := bytes.TrimRight(w.writer.Bytes()[w.lineStart:], " ")
+		if columns(string(line)) <= w.wrapWidth {
+			return
+		}
+
+		chosen := -1
+		for i, pos := range w.breaks {
+			if chosen >= 0 && columns(string(w.writer.Bytes()[w.lineStart:pos])) > w.wrapWidth {
+				break
+			}
+			// the next line must not start a block
+			next := w.writer.Bytes()[pos+1:]
+			if len(next) > 0 && !startsBlockRegex.Match(next) {
+				chosen = i
+			}
+		}
+		if chosen < 0 {
+			return
+		}
+
+		w.breakLine(chosen)
+	}
+}
+
+// breakLine replaces the space of the break opportunity i with a new line.
+func (w *outputWriter) breakLine(i int) {
+	pos := w.breaks[i]
+	tail := bytes.Clone(w.writer.Bytes()[pos+1:])
+	w.writer.Truncate(pos)
+	w.writer.WriteByte('\n')
+	w.lineStart = w.writer.Len()
+	w.writer.WriteString(w.linePrefix(w.containers.size(), false))
+	shift := w.writer.Len() - (pos + 1)
+	w.writer.Write(tail)
+
+	breaks := w.breaks[i+1:]
+	w.breaks = w.breaks[:0]
+	for _, b := range breaks {
+		w.breaks = append(w.breaks, b+shift)
+	}
+	if w.sentenceEnd > pos {
+		w.sentenceEnd += shift
+	}
+}
+
+// writeBeforeSpaces writes s before the trailing spaces of the current line,
+// like the closing delimiter of emphasis which must not follow a space.
+func (w *outputWriter) writeBeforeSpaces(s string) {
+	line := w.writer.Bytes()[w.lineStart:]
+	spaces := len(line) - len(bytes.TrimRight(line, " "))
+	if w.pendingNewlines > 0 || spaces == 0 || w.atBlockStart() {
+		w.WriteString(s)
+		return
+	}
+	end := w.writer.Len() - spaces
+	w.writer.Truncate(end)
+	w.breaks = slices.DeleteFunc(w.breaks, func(b int) bool { return b >= end })
+	if w.sentenceEnd >= end {
+		w.sentenceEnd = -1
+	}
+	w.WriteString(s + strings.Repeat(" ", spaces))
+}
+
 // String returns the complete string from the outputWriter.
 func (w *outputWriter) String() string {
-	return w.writer.String()
+	return w.writer.String() + strings.Repeat("\n", w.pendingNewlines)
 }
@@ -36,3 +36,209 @@ func TestOutputWriter(t *testing.T) {
 	}
 
 }
+
+func TestOutputWriterBlockquote(t *testing.T) {
+	writer := newOutputWriter()
+	writer.pushContainer(blockquoteContainer, "> ")
+	writer.WriteString("> quoted")
+	writer.WriteString("\n\n")
+	writer.WriteString("more")
+	writer.WriteString("\n\n")
+	writer.popContainer(blockquoteContainer)
+	writer.WriteString("after")
+
+	expected := "> quoted\n> \n> more\n\nafter"
+	if got := writer.String(); got != expected {
+		t.Errorf("got=%v\nexpected=%v", replaceNewline(got), replaceNewline(expected))
+	}
+}
+
+func TestOutputWriterListPrefix(t *testing.T) {
+	writer := newOutputWriter()
+	writer.WriteString("- ")
+	writer.pushContainer(listItemContainer, "\t")
+	writer.WriteString("first\n\n")
+	writer.WriteString("second\n")
+	writer.popContainer(listItemContainer)
+	writer.WriteString("\n- next")
+
+	expected := "- first\n\n\tsecond\n\n- next"
+	if got := writer.String(); got != expected {
+		t.Errorf("got=%v\nexpected=%v", replaceNewline(got), replaceNewline(expected))
+	}
+}
+
+func TestOutputWriterNestedContainers(t *testing.T) {
+	// a list inside a blockquote inside a list item
+	writer := newOutputWriter()
+	writer.WriteString("- ")
+	writer.pushContainer(listItemContainer, "  ")
+	writer.WriteString("item\n")
+	writer.pushContainer(blockquoteContainer, "> ")
+	writer.WriteString("> ")
+	writer.WriteString("- ")
+	writer.pushContainer(listItemContainer, "  ")
+	writer.WriteString("nested\n\n")
+	writer.WriteString("continued\n")
+	writer.popContainer(listItemContainer)
+	writer.WriteString("\n")
+	writer.popContainer(blockquoteContainer)
+	writer.WriteString("\n")
+	writer.popContainer(listItemContainer)
+	writer.WriteString("after")
+
+	expected := "- item\n  > - nested\n  > \n  >   continued\n\nafter"
+	if got := writer.String(); got != expected {
+		t.Errorf("got=%v\nexpected=%v", replaceNewline(got), replaceNewline(expected))
+	}
+}
+
+func TestOutputWriterWrap(t *testing.T) {
+	writer := newOutputWriter()
+	writer.wrapWidth = 12
+	writer.WriteString("> ")
+	writer.pushContainer(blockquoteContainer, "> ")
+	writer.writeProse("one two three four")
+	writer.WriteString("](https://example.com/long)")
+	writer.writeProse(" five - six")
+	writer.popContainer(blockquoteContainer)
+	writer.WriteString("\n")
+
+	expected := "> one two\n> three\n> four](https://example.com/long)\n> five - six\n"
+	if got := writer.String(); got != expected {
+		t.Errorf("got=%v\nexpected=%v", replaceNewline(got), replaceNewline(expected))
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Paragraph",
+			input:    `<p>The quick brown fox jumps over the lazy dog and keeps running far away.</p>`,
+			expected: "The quick brown fox jumps over\nthe lazy dog and keeps running\nfar away.\n\n",
+		},
+		{
+			name:     "List items",
+			input:    `<ul><li>A list item which is long enough to be wrapped twice over.</li><li>Short</li></ul>`,
+			expected: "- A list item which is long\n\tenough to be wrapped twice\n\tover.\n- Short\n\n",
+		},
+		{
+			name:     "Blockquote",
+			input:    `<blockquote><p>A quoted paragraph long enough to be wrapped.</p></blockquote>`,
+			expected: "> A quoted paragraph long\n> enough to be wrapped.\n\n",
+		},
+		{
+			name:     "Links and code are not broken",
+			input:    `<p>Read <a href="https://example.com/a/very/long/path">the docs</a> and run <code>go test ./...</code> now.</p>`,
+			expected: "Read [the\ndocs](https://example.com/a/very/long/path)\nand run `` go test ./... ``\nnow.\n\n",
+		},
+		{
+			name:     "No block start on the next line",
+			input:    `<p>aaaaaaaaaaaaaaaaaaaaaaaa bbbbb - ccc aaaaaaaaaaaaaaaaaaaaaaaa bbbbb 1. ccc aaaaaaaaaaaaaaaaaaaaaaaaa #tag</p>`,
+			expected: "aaaaaaaaaaaaaaaaaaaaaaaa\nbbbbb - ccc\naaaaaaaaaaaaaaaaaaaaaaaa\nbbbbb 1. ccc\naaaaaaaaaaaaaaaaaaaaaaaaa #tag\n\n",
+		},
+		{
+			name:     "Headings are not wrapped",
+			input:    `<h2>A heading which is longer than the wrap width</h2>`,
+			expected: "## A heading which is longer than the wrap width\n",
+		},
+		{
+			name:     "Long words",
+			input:    `<p>Short https://example.com/a/long/url/which/does/not/fit end</p>`,
+			expected: "Short\nhttps://example.com/a/long/url/which/does/not/fit\nend\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithWrap(30)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestSemanticLineBreaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Sentences",
+			input:    `<p>This is one. This is <em>two!</em> Is this three? Yes.</p>`,
+			expected: "This is one.\nThis is *two!*\nIs this three?\nYes.\n\n",
+		},
+		{
+			name:     "Abbreviations and initials",
+			input:    `<p>Fruits, e.g. apples, were sold by Dr. Smith and J. R. Jones. The end.</p>`,
+			expected: "Fruits, e.g. apples, were sold by Dr. Smith and J. R. Jones.\nThe end.\n\n",
+		},
+		{
+			name:     "Abbreviations of letters",
+			input:    `<p>The U.S. team left at 9 a.m. on Monday. They won.</p>`,
+			expected: "The U.S. team left at 9 a.m. on Monday.\nThey won.\n\n",
+		},
+		{
+			name:     "Custom abbreviations",
+			options:  []Option{WithAbbreviations("Fig.")},
+			input:    `<p>See fig. 2 for details. It shows the results.</p>`,
+			expected: "See fig. 2 for details.\nIt shows the results.\n\n",
+		},
+		{
+			name:     "Closing quotes",
+			input:    `<p>He said "stop." Then he left.</p>`,
+			expected: "He said \"stop.\"\nThen he left.\n\n",
+		},
+		{
+			name:     "Containers",
+			input:    `<ul><li>First sentence. Second sentence.</li></ul><blockquote><p>Quoted. Again.</p></blockquote>`,
+			expected: "- First sentence.\n\tSecond sentence.\n\n> Quoted.\n> Again.\n\n",
+		},
+		{
+			name:     "No block start on the next line",
+			input:    `<p>It costs 5. 1. Not a list.</p>`,
+			expected: "It costs 5. 1.\nNot a list.\n\n",
+		},
+		{
+			name:     "With wrapping",
+			options:  []Option{WithWrap(20)},
+			input:    `<p>Short one. A longer sentence which is wrapped.</p>`,
+			expected: "Short one.\nA longer sentence\nwhich is wrapped.\n\n",
+		},
+		{
+			name:     "Footnotes",
+			options:  []Option{WithWrap(30)},
+			input:    `<p>Text<sup><a href="#n1">1</a></sup>.</p><ol><li id="n1">First sentence of the note. A longer second sentence which is wrapped.</li></ol>`,
+			expected: "Text[^1].\n\n[^1]: First sentence of the note.\n    A longer second sentence which\n    is wrapped.\n",
+		},
+		{
+			name:     "Table cells on one line",
+			options:  []Option{WithWrap(10)},
+			input:    `<table><tr><th>Name</th></tr><tr><td>One sentence. Another one.</td></tr></table>`,
+			expected: "| Name                       |\n| -------------------------- |\n| One sentence. Another one. |\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := append([]Option{WithSemanticLineBreaks(true)}, test.options...)
+			output, err := NewConverter(options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package html2md
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// layoutTags only group or position their content, they are stripped instead
+// of dropped by UnknownDrop.
+var layoutTags = []string{
+	"html", "head", "body", "div", "span", "section", "article", "main", "header", "footer",
+	"aside", "nav", "address", "hgroup", "center", "font", "caption", "thead", "tbody",
+	"tfoot", "tr", "td", "th", "dl", "form", "fieldset", "label", "picture", "search",
+}
+
+// unsafeTags can run scripts, load content or change the page, they are never
+// passed through.
+var unsafeTags = []string{
+	"script", "style", "iframe", "frame", "frameset", "object", "embed", "applet", "base",
+	"link", "meta", "template", "noscript", "title", "svg", "math", "form", "input",
+	"button", "select", "textarea",
+}
+
+// htmlBlockTags start an HTML block in CommonMark, which ends at a blank line.
+var htmlBlockTags = []string{
+	"address", "article", "aside", "blockquote", "body", "caption", "center", "col",
+	"colgroup", "dd", "details", "dialog", "dir", "div", "dl", "dt", "fieldset",
+	"figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header",
+	"hr", "html", "legend", "li", "main", "menu", "nav", "ol", "optgroup", "option", "p",
+	"pre", "search", "section", "summary", "table", "tbody", "td", "tfoot", "th", "thead",
+	"tr", "ul",
+}
+
+var voidTags = []string{"area", "br", "col", "hr", "img", "source", "track", "wbr"}
+
+// attributes holding a URL, whose scheme is checked.
+var urlAttributes = []string{"href", "src", "cite", "action", "formaction", "poster", "background"}
+
+// isUnsafeURL reports whether the URL has a scheme able to run scripts or to
+// embed content. Browsers ignore whitespace and control characters in schemes.
+func isUnsafeURL(url string) bool {
+	url = strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(url))
+	return strings.HasPrefix(url, "javascript:") || strings.HasPrefix(url, "vbscript:") || strings.HasPrefix(url, "data:")
+}
+
+// sanitizedAttributes returns the allowed attributes of the node, rendered
+// with a leading space.
+func (c *Converter) sanitizedAttributes(node *html.Node) string {
+	var builder strings.Builder
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || strings.HasPrefix(key, "on") || !itemInSlice(key, c.options.allowedAttributes) {
+			continue
+		}
+		if itemInSlice(key, urlAttributes) && isUnsafeURL(attr.Val) {
+			continue
+		}
+		builder.WriteString(" " + key)
+		if attr.Val != "" {
+			builder.WriteString(`="` + html.EscapeString(attr.Val) + `"`)
+		}
+	}
+	return builder.String()
+}
+
+// hasElementChildren reports whether the node holds elements, and not only text.
+func hasElementChildren(node *html.Node) bool {
+	for child := range node.ChildNodes() {
+		if child.Type == html.ElementNode {
+			return true
+		}
+	}
+	return false
+}
+
+// newUnknownElement returns the element for a node without a markdown
+// equivalent, following the UnknownElementPolicy.
+func (c *Converter) newUnknownElement(node *html.Node) MarkdownElement {
+	switch c.options.unknownElements {
+	case UnknownDrop:
+		if !itemInSlice(node.Data, layoutTags) {
+			return NewDroppedTag()
+		}
+	case UnknownPassthrough:
+		if itemInSlice(node.Data, c.options.allowedTags) && !itemInSlice(node.Data, unsafeTags) {
+			// the markdown inside an HTML block is read only after a blank line
+			block := itemInSlice(node.Data, htmlBlockTags)
+			return NewRawHTMLTag(
+				node.Data, c.sanitizedAttributes(node), block,
+				block && hasElementChildren(node), itemInSlice(node.Data, voidTags),
+			)
+		}
+	}
+	return NewUnknownTag(node.Data)
+}
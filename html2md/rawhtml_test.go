@@ -0,0 +1,87 @@
+package html2md
+
+import "testing"
+
+func TestUnknownElements(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		input    string
+		expected string
+	}{
+		{
+			name:     "Strip",
+			input:    `<p>E = mc<sup>2</sup>, <abbr title="HyperText Markup Language">HTML</abbr> and <u>text</u>.</p>`,
+			expected: "E = mc2, HTML and text.\n\n",
+		},
+		{
+			name:     "Passthrough",
+			options:  []Option{WithUnknownElements(UnknownPassthrough)},
+			input:    `<p>E = mc<sup>2</sup>, <abbr title="HyperText Markup Language">HTML</abbr> and <u>some <em>text</em></u>.</p>`,
+			expected: "E = mc<sup>2</sup>, <abbr title=\"HyperText Markup Language\">HTML</abbr> and <u>some *text*</u>.\n\n",
+		},
+		{
+			name:     "Passthrough block",
+			options:  []Option{WithUnknownElements(UnknownPassthrough), WithAllowedTags("dialog", "figcaption")},
+			input:    `<p>Before</p><dialog open><figcaption>Title</figcaption><p>Hidden <b>text</b>.</p></dialog>`,
+			expected: "Before\n\n<dialog open>\n\n<figcaption>Title</figcaption>\n\nHidden **text**.\n\n</dialog>\n\n",
+		},
+		{
+			name:     "Passthrough sanitizes attributes",
+			options:  []Option{WithUnknownElements(UnknownPassthrough)},
+			input:    `<q cite="javascript:alert(1)" onclick="alert(1)" class="quote" title="a &quot;b&quot;">quote</q>`,
+			expected: "<q title=\"a &#34;b&#34;\">quote</q>",
+		},
+		{
+			name:     "Passthrough strips other tags",
+			options:  []Option{WithUnknownElements(UnknownPassthrough)},
+			input:    `<p><span class="x">one</span> <blink>two</blink> <button onclick="go()">three</button></p>`,
+			expected: "one two three\n\n",
+		},
+		{
+			name: "Allowlists",
+			options: []Option{
+				WithUnknownElements(UnknownPassthrough),
+				WithAllowedTags("span", "kbd", "script"),
+				WithAllowedAttributes("class"),
+			},
+			input:    `<p><span class="key" title="x"><kbd>Ctrl</kbd></span> <sup>2</sup></p><script>alert(1)</script>`,
+			expected: "<span class=\"key\"><kbd>Ctrl</kbd></span> 2\n\n",
+		},
+		{
+			name:     "Drop",
+			options:  []Option{WithUnknownElements(UnknownDrop)},
+			input:    `<div><p>Keep <span>this</span> <u>not this</u> and <abbr>this</abbr> neither.</p></div>`,
+			expected: "Keep this and neither.\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
+
+func TestIsUnsafeURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com":          false,
+		"/page#javascript:":            false,
+		"javascript:alert(1)":          true,
+		" JavaScript:alert(1)":         true,
+		"java\tscript:alert(1)":        true,
+		"vbscript:msgbox":              true,
+		"data:text/html;base64,PHNjcj": true,
+	}
+	for url, expected := range tests {
+		if got := isUnsafeURL(url); got != expected {
+			t.Errorf("isUnsafeURL(%q) = %v, expected %v", url, got, expected)
+		}
+	}
+}
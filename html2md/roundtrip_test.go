@@ -0,0 +1,101 @@
+package html2md
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"golang.org/x/net/html"
+)
+
+// blockStructure describes where the text of a document lives: one line per
+// text run, holding the chain of container elements around it.
+func blockStructure(t *testing.T, source string) string {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	containers := []string{"blockquote", "ul", "ol", "li", "pre"}
+	var lines []string
+	var walk func(node *html.Node, path []string)
+	walk = func(node *html.Node, path []string) {
+		if node.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(node.Data), " "); text != "" {
+				lines = append(lines, strings.Join(path, ">")+": "+text)
+			}
+			return
+		}
+		if node.Type == html.ElementNode && itemInSlice(node.Data, containers) {
+			path = append(path, node.Data)
+		}
+		for child := range node.ChildNodes() {
+			walk(child, path)
+		}
+	}
+	walk(doc, nil)
+	return strings.Join(lines, "\n")
+}
+
+// TestContainerRoundTrip converts nested containers to markdown, renders it back
+// with a CommonMark parser, and checks that every text stays in its containers.
+func TestContainerRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		input   string
+	}{
+		{
+			name:  "Paragraphs in list items",
+			input: `<ul><li><p>one</p><p>two</p></li><li><p>three</p></li></ul>`,
+		},
+		{
+			name:  "Blockquote in list item",
+			input: `<ul><li><p>said</p><blockquote><p>quote one</p><p>quote two</p></blockquote><p>after quote</p></li><li>next</li></ul>`,
+		},
+		{
+			name:  "List in blockquote in list item",
+			input: `<ol><li><p>item</p><blockquote><ul><li><p>deep one</p><p>deep two</p></li><li>deep three</li></ul><p>quoted</p></blockquote></li><li>last</li></ol>`,
+		},
+		{
+			name:  "List item in blockquote",
+			input: `<blockquote><ul><li><p>first</p><p>second</p></li></ul><blockquote><p>nested quote</p></blockquote></blockquote><p>outside</p>`,
+		},
+		{
+			name: "Code block in nested list item",
+			input: `<ul><li>outer<ul><li><p>inner</p><pre><code>code line
+
+after blank</code></pre></li></ul></li><li>sibling</li></ul>`,
+		},
+		{
+			name:    "Wrapped nested containers",
+			options: []Option{WithWrap(16)},
+			input:   `<ul><li><p>a list item with some words - 1. to wrap</p><blockquote><p>a quoted paragraph inside the item + more</p><ul><li>and a nested list item to wrap as well</li></ul></blockquote></li></ul>`,
+		},
+		{
+			name:    "Marker aligned indentation",
+			options: []Option{WithListIndent(IndentMarker), WithBullets("*", "-")},
+			input:   `<ol start="9"><li><p>nine</p><ul><li><p>bullet</p><blockquote><p>quoted</p></blockquote></li></ul></li><li><p>ten</p><p>more ten</p></li></ol>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			markdown, err := NewConverter(test.options...).ConvertString(test.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var rendered bytes.Buffer
+			if err := goldmark.Convert([]byte(markdown), &rendered); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := blockStructure(t, test.input)
+			if got := blockStructure(t, rendered.String()); got != expected {
+				t.Errorf("structure changed in round trip\nMarkdown:\n%s\nGot:\n%s\nExpected:\n%s", markdown, got, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package html2md
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+type alignment uint
+
+const (
+	alignNone alignment = iota
+	alignLeft
+	alignCenter
+	alignRight
+)
+
+// cellAlignment returns the alignment of a cell, from its `align` attribute or
+// its `text-align` style.
+func cellAlignment(cell *html.Node) alignment {
+	align := findAttribute(cell, "align")
+	if style := styleProperty(cell, "text-align"); style != "" {
+		align = style
+	}
+	switch strings.ToLower(strings.TrimSpace(align)) {
+	case "left", "start":
+		return alignLeft
+	case "center":
+		return alignCenter
+	case "right", "end":
+		return alignRight
+	default:
+		return alignNone
+	}
+}
+
+// tableRows returns the rows of the table, the rows of the header first.
+func tableRows(table *html.Node) []*html.Node {
+	var head, body, foot []*html.Node
+	for child := range table.ChildNodes() {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.Data {
+		case "tr":
+			body = append(body, child)
+		case "thead", "tbody", "tfoot":
+			for row := range child.ChildNodes() {
+				if row.Type != html.ElementNode || row.Data != "tr" {
+					continue
+				}
+				switch child.Data {
+				case "thead":
+					head = append(head, row)
+				case "tbody":
+					body = append(body, row)
+				default:
+					foot = append(foot, row)
+				}
+			}
+		}
+	}
+	return append(append(head, body...), foot...)
+}
+
+// tableCells returns the td and th nodes of a row.
+func tableCells(row *html.Node) []*html.Node {
+	var cells []*html.Node
+	for child := range row.ChildNodes() {
+		if child.Type == html.ElementNode && (child.Data == "td" || child.Data == "th") {
+			cells = append(cells, child)
+		}
+	}
+	return cells
+}
+
+// tableCell converts the content of a cell to a single line, its blocks are
+// separated by `<br>` like its line breaks.
+func (c *Converter) tableCell(cell *html.Node) string {
+	c.cellDepth++
+	defer func() { c.cellDepth-- }()
+	var lines []string
+	for _, line := range strings.Split(c.renderChildren(cell), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.ReplaceAll(strings.Join(lines, "<br>"), "|", `\|`)
+}
+
+// padCell pads the text of a cell to the width of its column.
+func padCell(text string, width int, align alignment) string {
+	padding := width - utf8.RuneCountInString(text)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", padding) + text
+	case alignCenter:
+		return strings.Repeat(" ", padding/2) + text + strings.Repeat(" ", padding-padding/2)
+	default:
+		return text + strings.Repeat(" ", padding)
+	}
+}
+
+// delimiterCell returns the cell of the delimiter row under the header.
+func delimiterCell(width int, align alignment) string {
+	switch align {
+	case alignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case alignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case alignRight:
+		return strings.Repeat("-", width-1) + ":"
+	default:
+		return strings.Repeat("-", width)
+	}
+}
+
+// htmlTable returns the HTML source of the table without blank lines, which
+// would end the HTML block.
+func htmlTable(node *html.Node) string {
+	var lines []string
+	for _, line := range strings.Split(renderHTML(node), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// cellBlockTags are the blocks which can't be written on the single line of
+// a cell of a pipe table.
+var cellBlockTags = []string{"pre", "table", "ul", "ol", "dl", "blockquote", "hr", "h1", "h2", "h3", "h4", "h5", "h6"}
+
+// hasBlockCell reports whether a cell of the rows holds a block which can't be
+// written on a single line.
+func hasBlockCell(rows []*html.Node) bool {
+	for _, row := range rows {
+		for _, cell := range tableCells(row) {
+			for n := range cell.Descendants() {
+				if n.Type == html.ElementNode && itemInSlice(n.Data, cellBlockTags) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// newTable creates the element for a table node, a pipe table or HTML when the
+// flavor has no tables or a cell holds blocks like code or lists. The first row
+// is the header row of a pipe table, whose cells set the alignment of the
+// columns.
+func (c *Converter) newTable(node *html.Node) MarkdownElement {
+	if !c.options.flavor.supports(tablesFeature) {
+		return NewTableTag(htmlTable(node))
+	}
+	rows := tableRows(node)
+	if len(rows) == 0 {
+		return NewDroppedTag()
+	}
+	if hasBlockCell(rows) {
+		return NewTableTag(htmlTable(node))
+	}
+
+	var cells [][]string
+	var aligns []alignment
+	columns := 0
+	for i, row := range rows {
+		var texts []string
+		for _, cell := range tableCells(row) {
+			text := c.tableCell(cell)
+			span, err := strconv.Atoi(findAttribute(cell, "colspan"))
+			if err != nil || span < 1 {
+				span = 1
+			}
+			texts = append(texts, text)
+			for range span - 1 {
+				texts = append(texts, "")
+			}
+			if i == 0 {
+				for range span {
+					aligns = append(aligns, cellAlignment(cell))
+				}
+			}
+		}
+		cells = append(cells, texts)
+		columns = max(columns, len(texts))
+	}
+	if columns == 0 {
+		return NewDroppedTag()
+	}
+
+	widths := make([]int, columns)
+	for i := range widths {
+		widths[i] = 3
+		for _, row := range cells {
+			if i < len(row) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+			}
+		}
+	}
+	for len(aligns) < columns {
+		aligns = append(aligns, alignNone)
+	}
+
+	var builder strings.Builder
+	writeRow := func(row []string) {
+		builder.WriteString("|")
+		for i := range columns {
+			text := ""
+			if i < len(row) {
+				text = row[i]
+			}
+			builder.WriteString(" " + padCell(text, widths[i], aligns[i]) + " |")
+		}
+		builder.WriteString("\n")
+	}
+	writeRow(cells[0])
+	delimiters := make([]string, columns)
+	for i := range columns {
+		delimiters[i] = delimiterCell(widths[i], aligns[i])
+	}
+	writeRow(delimiters)
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+
+	for child := range node.ChildNodes() {
+		if child.Type != html.ElementNode || child.Data != "caption" {
+			continue
+		}
+		caption := c.renderLine(child)
+		switch c.options.flavor {
+		case Pandoc:
+			builder.WriteString("\nTable: " + caption + "\n")
+		case MultiMarkdown:
+			builder.WriteString("[" + caption + "]\n")
+		default:
+			builder.WriteString("\n*" + caption + "*\n")
+		}
+	}
+	return NewTableTag(builder.String())
+}
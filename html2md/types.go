@@ -2,7 +2,9 @@ package html2md
 
 import (
 	"fmt"
+	"html"
 	"strings"
+	"unicode/utf8"
 )
 
 type MarkdownElementType int
@@ -10,12 +12,12 @@ type MarkdownElementType int
 const (
 	Bold MarkdownElementType = iota
 	Italic
-	H1
-	H2
-	H3
-	H4
-	H5
-	H6
+	H1 // Deprecated: headings are of type Heading.
+	H2 // Deprecated: headings are of type Heading.
+	H3 // Deprecated: headings are of type Heading.
+	H4 // Deprecated: headings are of type Heading.
+	H5 // Deprecated: headings are of type Heading.
+	H6 // Deprecated: headings are of type Heading.
 	Paragraph
 	Anchor
 	Image
@@ -28,6 +30,23 @@ const (
 	BR
 	HR
 	Unknown
+	// the types added later follow, so that the values of the others are kept
+	Heading
+	AnchorTarget
+	Figure
+	InlineMath
+	DisplayMath
+	Callout
+	Details
+	Strikethrough
+	TaskMarker
+	DefinitionTerm
+	Definition
+	Table
+	Media
+	RawHTML
+	RawHTMLBlock
+	Dropped
 )
 
 type MarkdownElement interface {
@@ -36,92 +55,190 @@ type MarkdownElement interface {
 	EndCode() string
 }
 
+// containerElement is implemented by the elements holding other blocks,
+// the lines of their content are prefixed while they are open.
+type containerElement interface {
+	MarkdownElement
+	container() container
+}
+
+// freshLineElements must start on a line of their own.
+var freshLineElements = []MarkdownElementType{Blockquote, FencedCode, DisplayMath, Callout, Details, DefinitionTerm, Table, RawHTMLBlock}
+
+// leafElements are written entirely from their HTML node, the converter
+// doesn't descend into their children.
+var leafElements = []MarkdownElementType{Heading, InlineMath, DisplayMath, Media, TaskMarker, Table, Dropped}
+
+// HeadingTag is a heading written in full from its rendered content.
+type HeadingTag struct {
+	level   int
+	text    string
+	id      string
+	style   HeadingStyle
+	closed  bool
+	idStyle HeadingIDStyle
+	flavor  Flavor
+}
+
+func (h HeadingTag) Type() MarkdownElementType {
+	return Heading
+}
+
+// StartCode returns the whole heading, as an ATX heading or, for levels 1 and 2
+// with HeadingSetext, as a setext heading underlined by `=` or `-`.
+func (h HeadingTag) StartCode() string {
+	text := h.text
+	attributes := ""
+	if h.id != "" {
+		switch h.idStyle {
+		case HeadingIDAnchor:
+			text = fmt.Sprintf(`<a id="%v"></a>`, html.EscapeString(h.id)) + text
+		case HeadingIDAttribute:
+			attributes = " {#" + h.id + "}"
+			if h.flavor == MultiMarkdown {
+				attributes = " [" + h.id + "]"
+			}
+		}
+	}
+
+	if h.style == HeadingSetext && h.level <= 2 && h.text != "" {
+		underline := "="
+		if h.level == 2 {
+			underline = "-"
+		}
+		text += attributes
+		return text + "\n" + strings.Repeat(underline, max(utf8.RuneCountInString(text), 3))
+	}
+
+	hashes := strings.Repeat("#", h.level)
+	if h.closed && h.text != "" {
+		return hashes + " " + text + " " + hashes + attributes
+	}
+	return hashes + " " + text + attributes
+}
+func (h HeadingTag) EndCode() string {
+	return "\n"
+}
+func NewHeadingTag(level int, text, id string, style HeadingStyle, closed bool, idStyle HeadingIDStyle, flavor Flavor) *HeadingTag {
+	return &HeadingTag{level: level, text: text, id: id, style: style, closed: closed, idStyle: idStyle, flavor: flavor}
+}
+
+// H1Tag is the opening of a level 1 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H1Tag struct{}
 
 func (h1 H1Tag) Type() MarkdownElementType {
 	return H1
 }
 func (h1 H1Tag) StartCode() string {
-	return "# "
+	return HeadingTag{level: 1}.StartCode()
 }
 func (h1 H1Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 1}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH1Tag() *H1Tag {
 	return &H1Tag{}
 }
 
+// H2Tag is the opening of a level 2 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H2Tag struct{}
 
 func (h2 H2Tag) Type() MarkdownElementType {
 	return H2
 }
 func (h2 H2Tag) StartCode() string {
-	return "## "
+	return HeadingTag{level: 2}.StartCode()
 }
 func (h2 H2Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 2}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH2Tag() *H2Tag {
 	return &H2Tag{}
 }
 
+// H3Tag is the opening of a level 3 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H3Tag struct{}
 
 func (h3 H3Tag) Type() MarkdownElementType {
 	return H3
 }
 func (h3 H3Tag) StartCode() string {
-	return "### "
+	return HeadingTag{level: 3}.StartCode()
 }
 func (h3 H3Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 3}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH3Tag() *H3Tag {
 	return &H3Tag{}
 }
 
+// H4Tag is the opening of a level 4 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H4Tag struct{}
 
 func (h4 H4Tag) Type() MarkdownElementType {
 	return H4
 }
 func (h4 H4Tag) StartCode() string {
-	return "#### "
+	return HeadingTag{level: 4}.StartCode()
 }
 func (h4 H4Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 4}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH4Tag() *H4Tag {
 	return &H4Tag{}
 }
 
+// H5Tag is the opening of a level 5 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H5Tag struct{}
 
 func (h5 H5Tag) Type() MarkdownElementType {
 	return H5
 }
 func (h5 H5Tag) StartCode() string {
-	return "##### "
+	return HeadingTag{level: 5}.StartCode()
 }
 func (h5 H5Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 5}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH5Tag() *H5Tag {
 	return &H5Tag{}
 }
 
+// H6Tag is the opening of a level 6 heading.
+//
+// Deprecated: use NewHeadingTag, which writes the whole heading.
 type H6Tag struct{}
 
 func (h6 H6Tag) Type() MarkdownElementType {
 	return H6
 }
 func (h6 H6Tag) StartCode() string {
-	return "###### "
+	return HeadingTag{level: 6}.StartCode()
 }
 func (h6 H6Tag) EndCode() string {
-	return "\n"
+	return HeadingTag{level: 6}.EndCode()
 }
+
+// Deprecated: use NewHeadingTag.
 func NewH6Tag() *H6Tag {
 	return &H6Tag{}
 }
@@ -156,8 +273,6 @@ func NewItalicTag() *ItalicTag {
 	return &ItalicTag{}
 }
 
-// todo strikethrough tag (GFM)
-
 type ParagraphTag struct{}
 
 func (p ParagraphTag) Type() MarkdownElementType {
@@ -194,22 +309,72 @@ func NewAnchorTag(href, title string) *AnchorTag {
 	return &AnchorTag{href: href, title: title}
 }
 
+// AnchorTargetTag marks a position in the page which in-page links point to,
+// as an HTML anchor or a Pandoc span. MultiMarkdown has no spans, the anchor
+// is used instead.
+type AnchorTargetTag struct {
+	id     string
+	style  HeadingIDStyle
+	flavor Flavor
+}
+
+func (a AnchorTargetTag) Type() MarkdownElementType {
+	return AnchorTarget
+}
+func (a AnchorTargetTag) StartCode() string {
+	if a.style == HeadingIDAttribute && a.flavor != MultiMarkdown {
+		return "[]{#" + a.id + "}"
+	}
+	return fmt.Sprintf(`<a id="%v"></a>`, html.EscapeString(a.id))
+}
+func (a AnchorTargetTag) EndCode() string {
+	return ""
+}
+func NewAnchorTargetTag(id string, style HeadingIDStyle, flavor Flavor) *AnchorTargetTag {
+	return &AnchorTargetTag{id: id, style: style, flavor: flavor}
+}
+
 type ImageTag struct {
 	src     string
 	altText string
+	title   string
 }
 
 func (img ImageTag) Type() MarkdownElementType {
 	return Image
 }
 func (img ImageTag) StartCode() string {
+	if img.title != "" {
+		return fmt.Sprintf("![%v](%v \"%v\")", img.altText, img.src, strings.ReplaceAll(img.title, `"`, `\"`))
+	}
 	return fmt.Sprintf("![%v](%v)", img.altText, img.src)
 }
 func (img ImageTag) EndCode() string {
 	return "\n"
 }
-func NewImageTag(src, altText string) *ImageTag {
-	return &ImageTag{src: src, altText: altText}
+func NewImageTag(src, altText, title string) *ImageTag {
+	return &ImageTag{src: src, altText: altText, title: title}
+}
+
+// FigureTag holds an image or another illustration along with its caption.
+type FigureTag struct {
+	caption string
+}
+
+func (f FigureTag) Type() MarkdownElementType {
+	return Figure
+}
+func (f FigureTag) StartCode() string {
+	return ""
+}
+func (f FigureTag) EndCode() string {
+	if f.caption == "" {
+		return "\n\n"
+	}
+	return "\n\n*" + f.caption + "*\n\n"
+}
+func NewFigureTag(caption string) *FigureTag {
+	return &FigureTag{caption: caption}
 }
 
 type ListOrdering uint
@@ -245,43 +410,286 @@ type ListItemTag struct {
 	depth  int
 	type_  ListOrdering
 	number string
+	bullet string
+	indent ListIndent
 }
 
 func (li ListItemTag) Type() MarkdownElementType {
 	return ListItem
 }
 
-func (li ListItemTag) StartCode() string {
+func (li ListItemTag) marker() string {
 	if li.type_ == UnorderedList {
-		return strings.Repeat("\t", li.depth) + "- "
+		return li.bullet + " "
+	}
+	return fmt.Sprintf("%v. ", li.number)
+}
+
+// StartCode returns the marker of the item, its indentation comes from the
+// list prefixes of the outputWriter.
+func (li ListItemTag) StartCode() string {
+	return li.marker()
+}
+
+// container returns the indentation of the lines of the item after the one
+// holding the marker.
+func (li ListItemTag) container() container {
+	switch {
+	case li.indent == IndentTab:
+		return container{kind: listItemContainer, prefix: "\t"}
+	case li.indent == IndentMarker:
+		return container{kind: listItemContainer, prefix: strings.Repeat(" ", utf8.RuneCountInString(li.marker()))}
+	default:
+		return container{kind: listItemContainer, prefix: strings.Repeat(" ", int(li.indent))}
 	}
-	return fmt.Sprintf("%v%v. ", strings.Repeat("\t", li.depth), li.number)
 }
 func (li ListItemTag) EndCode() string {
 	return "\n"
 }
-func NewListItemTag(depth int, type_ ListOrdering, number string) *ListItemTag {
-	return &ListItemTag{depth: depth, type_: type_, number: number}
+func NewListItemTag(depth int, type_ ListOrdering, number, bullet string, indent ListIndent) *ListItemTag {
+	return &ListItemTag{depth: depth, type_: type_, number: number, bullet: bullet, indent: indent}
 }
 
-type BlockquoteTag struct {
-	insideList bool
-}
+type BlockquoteTag struct{}
 
 func (bl BlockquoteTag) Type() MarkdownElementType {
 	return Blockquote
 }
 func (bl BlockquoteTag) StartCode() string {
-	if bl.insideList {
-		return " > "
-	}
 	return "> "
 }
 func (bl BlockquoteTag) EndCode() string {
 	return "\n\n"
 }
-func NewBlockquoteTag(insideList bool) *BlockquoteTag {
-	return &BlockquoteTag{insideList: insideList}
+func (bl BlockquoteTag) container() container {
+	return container{kind: blockquoteContainer, prefix: "> "}
+}
+func NewBlockquoteTag() *BlockquoteTag {
+	return &BlockquoteTag{}
+}
+
+// CalloutTag is an admonition written as a GitHub alert, an Obsidian callout,
+// a Pandoc fenced div or a blockquote led by its title, depending on the flavor.
+type CalloutTag struct {
+	type_  string
+	title  string
+	flavor Flavor
+}
+
+func (cl CalloutTag) Type() MarkdownElementType {
+	return Callout
+}
+func (cl CalloutTag) StartCode() string {
+	switch cl.flavor {
+	case Obsidian:
+		return strings.TrimSpace(fmt.Sprintf("> [!%v] %v", cl.type_, cl.title)) + "\n"
+	case Pandoc:
+		if cl.title != "" {
+			return fmt.Sprintf("::: %v\n::: title\n%v\n:::\n\n", cl.type_, cl.title)
+		}
+		return fmt.Sprintf("::: %v\n", cl.type_)
+	case CommonMark, MultiMarkdown:
+		// a blockquote led by the title, or by the type
+		title := cl.title
+		if title == "" {
+			title = strings.ToUpper(cl.type_[:1]) + cl.type_[1:]
+		}
+		return fmt.Sprintf("> **%v**\n\n", title)
+	default:
+		if cl.title != "" {
+			return fmt.Sprintf("> [!%v]\n**%v**\n\n", gitHubAlerts[cl.type_], cl.title)
+		}
+		return fmt.Sprintf("> [!%v]\n", gitHubAlerts[cl.type_])
+	}
+}
+func (cl CalloutTag) EndCode() string {
+	if cl.flavor == Pandoc {
+		return "\n:::\n\n"
+	}
+	return "\n\n"
+}
+
+// container returns the blockquote prefix of the body, which is not prefixed
+// in a Pandoc div.
+func (cl CalloutTag) container() container {
+	if cl.flavor == Pandoc {
+		return container{kind: calloutContainer, prefix: ""}
+	}
+	return container{kind: calloutContainer, prefix: "> "}
+}
+func NewCalloutTag(type_, title string, flavor Flavor) *CalloutTag {
+	return &CalloutTag{type_: type_, title: title, flavor: flavor}
+}
+
+// DetailsTag is a collapsible section, written as a `<details>` element around
+// a markdown body, as a foldable Obsidian callout or as a heading.
+type DetailsTag struct {
+	summary string
+	open    bool
+	style   DetailsStyle
+	// the heading of DetailsHeading
+	heading *HeadingTag
+}
+
+func (d DetailsTag) Type() MarkdownElementType {
+	return Details
+}
+func (d DetailsTag) StartCode() string {
+	switch d.style {
+	case DetailsCallout:
+		fold := "-"
+		if d.open {
+			fold = "+"
+		}
+		return fmt.Sprintf("> [!faq]%v %v\n", fold, d.summary)
+	case DetailsHeading:
+		return d.heading.StartCode() + d.heading.EndCode() + "\n"
+	default:
+		open := ""
+		if d.open {
+			open = " open"
+		}
+		// the body is read as markdown after a blank line
+		return fmt.Sprintf("<details%v>\n<summary>%v</summary>\n\n", open, html.EscapeString(d.summary))
+	}
+}
+func (d DetailsTag) EndCode() string {
+	if d.style == DetailsHTML {
+		return "\n\n</details>\n\n"
+	}
+	return "\n\n"
+}
+
+// container returns the blockquote prefix of the body of a callout, the body
+// is not prefixed otherwise.
+func (d DetailsTag) container() container {
+	if d.style == DetailsCallout {
+		return container{kind: calloutContainer, prefix: "> "}
+	}
+	return container{kind: calloutContainer, prefix: ""}
+}
+func NewDetailsTag(summary string, open bool, style DetailsStyle, heading *HeadingTag) *DetailsTag {
+	return &DetailsTag{summary: summary, open: open, style: style, heading: heading}
+}
+
+// StrikethroughTag is deleted text, written between `~~` or as `<del>` HTML
+// when the flavor has no strikethrough.
+type StrikethroughTag struct {
+	flavor Flavor
+}
+
+func (st StrikethroughTag) Type() MarkdownElementType {
+	return Strikethrough
+}
+func (st StrikethroughTag) StartCode() string {
+	if st.flavor.supports(strikethroughFeature) {
+		return "~~"
+	}
+	return "<del>"
+}
+func (st StrikethroughTag) EndCode() string {
+	if st.flavor.supports(strikethroughFeature) {
+		return "~~"
+	}
+	return "</del>"
+}
+func NewStrikethroughTag(flavor Flavor) *StrikethroughTag {
+	return &StrikethroughTag{flavor: flavor}
+}
+
+// TaskMarkerTag is the checkbox of a task list item, written as `[x]` or, when
+// the flavor has no task lists, as a ballot box.
+type TaskMarkerTag struct {
+	checked bool
+	flavor  Flavor
+}
+
+func (t TaskMarkerTag) Type() MarkdownElementType {
+	return TaskMarker
+}
+func (t TaskMarkerTag) StartCode() string {
+	switch {
+	case t.flavor.supports(taskListsFeature) && t.checked:
+		return "[x] "
+	case t.flavor.supports(taskListsFeature):
+		return "[ ] "
+	case t.checked:
+		return "☒ "
+	default:
+		return "☐ "
+	}
+}
+func (t TaskMarkerTag) EndCode() string { return "" }
+func NewTaskMarkerTag(checked bool, flavor Flavor) *TaskMarkerTag {
+	return &TaskMarkerTag{checked: checked, flavor: flavor}
+}
+
+// DefinitionTermTag is the term of a definition list, on a line of its own, or
+// a bold paragraph when the flavor has no definition lists.
+type DefinitionTermTag struct {
+	flavor Flavor
+}
+
+func (dt DefinitionTermTag) Type() MarkdownElementType {
+	return DefinitionTerm
+}
+func (dt DefinitionTermTag) StartCode() string {
+	if dt.flavor.supports(definitionListsFeature) {
+		return ""
+	}
+	return "**"
+}
+func (dt DefinitionTermTag) EndCode() string {
+	if dt.flavor.supports(definitionListsFeature) {
+		return "\n"
+	}
+	return "**\n\n"
+}
+func NewDefinitionTermTag(flavor Flavor) *DefinitionTermTag {
+	return &DefinitionTermTag{flavor: flavor}
+}
+
+// DefinitionTag is the definition of a term, after a `:` marker, or a plain
+// block when the flavor has no definition lists.
+type DefinitionTag struct {
+	flavor Flavor
+}
+
+func (dd DefinitionTag) Type() MarkdownElementType {
+	return Definition
+}
+func (dd DefinitionTag) StartCode() string {
+	if dd.flavor.supports(definitionListsFeature) {
+		return ":   "
+	}
+	return ""
+}
+func (dd DefinitionTag) EndCode() string { return "\n\n" }
+
+// container returns the indentation of the lines of a definition after the
+// marker, the lines of a plain block are not indented.
+func (dd DefinitionTag) container() container {
+	if dd.flavor.supports(definitionListsFeature) {
+		return container{kind: definitionContainer, prefix: "    "}
+	}
+	return container{kind: definitionContainer, prefix: ""}
+}
+func NewDefinitionTag(flavor Flavor) *DefinitionTag {
+	return &DefinitionTag{flavor: flavor}
+}
+
+// TableTag is a table written in full, as a pipe table or as HTML.
+type TableTag struct {
+	table string
+}
+
+func (t TableTag) Type() MarkdownElementType {
+	return Table
+}
+func (t TableTag) StartCode() string { return t.table }
+func (t TableTag) EndCode() string   { return "\n" }
+func NewTableTag(table string) *TableTag {
+	return &TableTag{table: table}
 }
 
 type InlineCodeTag struct{}
@@ -327,15 +735,48 @@ func NewPreTag() *PreTag {
 	return &PreTag{}
 }
 
-type BRTag struct{}
+// BRTag is a hard line break. Inside headings, which are a single line, it
+// is a space.
+type BRTag struct {
+	style LineBreakStyle
+	// the break is inside a heading, or inside a table cell where it is
+	// written as `<br>`
+	heading bool
+	cell    bool
+}
 
 func (p BRTag) Type() MarkdownElementType {
 	return BR
 }
-func (p BRTag) StartCode() string { return "  \n" }
-func (p BRTag) EndCode() string   { return "" }
-func NewBRTag() *BRTag {
-	return &BRTag{}
+func (p BRTag) StartCode() string {
+	switch {
+	case p.heading:
+		return " "
+	case p.cell:
+		return "<br>"
+	case p.style == BreakBackslash:
+		return "\\\n"
+	case p.style == BreakHTML:
+		return "<br>\n"
+	default:
+		return "  \n"
+	}
+}
+func (p BRTag) EndCode() string { return "" }
+func NewBRTag(style LineBreakStyle, heading, cell bool) *BRTag {
+	return &BRTag{style: style, heading: heading, cell: cell}
+}
+
+// ParagraphBreakTag ends a paragraph, in place of consecutive line breaks.
+type ParagraphBreakTag struct{}
+
+func (p ParagraphBreakTag) Type() MarkdownElementType {
+	return BR
+}
+func (p ParagraphBreakTag) StartCode() string { return "\n\n" }
+func (p ParagraphBreakTag) EndCode() string   { return "" }
+func NewParagraphBreakTag() *ParagraphBreakTag {
+	return &ParagraphBreakTag{}
 }
 
 type HRTag struct{}
@@ -349,6 +790,67 @@ func NewHRTag() *HRTag {
 	return &HRTag{}
 }
 
+// MathTag is a TeX formula, written between `$` delimiters, or as code in
+// CommonMark which has no math.
+type MathTag struct {
+	tex     string
+	display bool
+	flavor  Flavor
+}
+
+func (m MathTag) Type() MarkdownElementType {
+	if m.display {
+		return DisplayMath
+	}
+	return InlineMath
+}
+func (m MathTag) StartCode() string {
+	if !m.flavor.supports(mathFeature) {
+		if m.display {
+			return "```math\n" + m.tex + "\n```\n\n"
+		}
+		return "`` " + m.tex + " ``"
+	}
+	if m.display {
+		return "$$\n" + m.tex + "\n$$\n\n"
+	}
+	return "$" + m.tex + "$"
+}
+func (m MathTag) EndCode() string { return "" }
+func NewMathTag(tex string, display bool, flavor Flavor) *MathTag {
+	tex = strings.TrimSpace(tex)
+	if !display {
+		tex = collapseWhitespace(tex)
+	}
+	return &MathTag{tex: tex, display: display, flavor: flavor}
+}
+
+type MediaTag struct {
+	src       string
+	title     string
+	thumbnail string
+	html      string
+	style     MediaStyle
+}
+
+func (m MediaTag) Type() MarkdownElementType {
+	return Media
+}
+func (m MediaTag) StartCode() string {
+	switch {
+	case m.style == MediaHTML:
+		return m.html
+	case m.style == MediaThumbnail && m.thumbnail != "":
+		return fmt.Sprintf("[![%v](%v)](%v)", m.title, m.thumbnail, m.src)
+	default:
+		return fmt.Sprintf("[%v](%v)", m.title, m.src)
+	}
+}
+func (m MediaTag) EndCode() string { return "\n" }
+func NewMediaTag(src, title, thumbnail, html string, style MediaStyle) *MediaTag {
+	return &MediaTag{src: src, title: title, thumbnail: thumbnail, html: html, style: style}
+}
+
 type UnknownTag struct {
 	data string
 }
@@ -361,3 +863,56 @@ func (u UnknownTag) EndCode() string   { return "" }
 func NewUnknownTag(data string) *UnknownTag {
 	return &UnknownTag{data: data}
 }
+
+// RawHTMLTag is an element passed through as HTML, around its converted content.
+// Block elements are separated from their content by blank lines, so that the
+// content is still read as markdown.
+type RawHTMLTag struct {
+	tag        string
+	attributes string
+	block      bool
+	// the content is markdown, which must be separated from the tags of a block
+	blockContent bool
+	void         bool
+}
+
+func (r RawHTMLTag) Type() MarkdownElementType {
+	if r.block {
+		return RawHTMLBlock
+	}
+	return RawHTML
+}
+func (r RawHTMLTag) StartCode() string {
+	code := "<" + r.tag + r.attributes + ">"
+	if r.blockContent {
+		code += "\n\n"
+	}
+	return code
+}
+func (r RawHTMLTag) EndCode() string {
+	switch {
+	case r.void:
+		return ""
+	case r.blockContent:
+		return "\n\n</" + r.tag + ">\n\n"
+	case r.block:
+		return "</" + r.tag + ">\n\n"
+	default:
+		return "</" + r.tag + ">"
+	}
+}
+func NewRawHTMLTag(tag, attributes string, block, blockContent, void bool) *RawHTMLTag {
+	return &RawHTMLTag{tag: tag, attributes: attributes, block: block, blockContent: blockContent, void: void}
+}
+
+// DroppedTag is an element left out of the output with its content.
+type DroppedTag struct{}
+
+func (d DroppedTag) Type() MarkdownElementType {
+	return Dropped
+}
+func (d DroppedTag) StartCode() string { return "" }
+func (d DroppedTag) EndCode() string   { return "" }
+func NewDroppedTag() *DroppedTag {
+	return &DroppedTag{}
+}
@@ -0,0 +1,260 @@
+package html2md
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// officeElementPrefixes are the namespaces of the Office elements whose
+// content is only read by Office, like `<o:p>` or VML shapes. The elements of
+// other namespaces, like the `<st1:place>` smart tags, wrap text.
+var officeElementPrefixes = []string{"o:", "v:", "w:", "m:"}
+
+// msoListRegex matches the `mso-list` style of a list paragraph, as in
+// `mso-list:l0 level2 lfo1`.
+var msoListRegex = regexp.MustCompile(`(?i)^l(\d+)\s+level(\d+)`)
+
+// orderedMarkerRegex matches the marker of a numbered list paragraph, as in
+// `1.`, `b)` or `(iv)`.
+var orderedMarkerRegex = regexp.MustCompile(`^\(?([0-9]+|[a-zA-Z]+)[.)]$`)
+
+var romanNumeralRegex = regexp.MustCompile(`(?i)^[ivxlcdm]+$`)
+
+// cleanWordHTML rewrites the HTML of Microsoft Word and Outlook: the list
+// paragraphs become lists, and the Office elements, the list markers and the
+// spacing of empty paragraphs are removed.
+func cleanWordHTML(doc *html.Node) {
+	rebuildWordLists(doc)
+	removeWordNoise(doc)
+}
+
+// wordListLevel returns the list and the level, starting at 1, of a Word list
+// paragraph.
+func wordListLevel(node *html.Node) (list, level int, ok bool) {
+	if node.Type != html.ElementNode || node.DataAtom != atom.P {
+		return 0, 0, false
+	}
+	match := msoListRegex.FindStringSubmatch(styleProperty(node, "mso-list"))
+	if match == nil {
+		return 0, 0, false
+	}
+	list, _ = strconv.Atoi(match[1])
+	level, _ = strconv.Atoi(match[2])
+	return list, max(level, 1), true
+}
+
+// isConditionalComment reports whether the node is the start of the content
+// which Word writes for browsers without a feature, like the list markers in
+// `<![if !supportLists]>`.
+func isConditionalComment(node *html.Node) bool {
+	return node.Type == html.CommentNode && strings.HasPrefix(node.Data, "[if !support")
+}
+
+func isEndifComment(node *html.Node) bool {
+	return node.Type == html.CommentNode && node.Data == "[endif]"
+}
+
+// wordListMarker returns the bullet or number written before the text of a
+// list paragraph, like `·` or `1.`.
+func wordListMarker(node *html.Node) string {
+	var marker strings.Builder
+	var walk func(n *html.Node) bool
+	walk = func(n *html.Node) bool {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if strings.EqualFold(styleProperty(child, "mso-list"), "Ignore") {
+				marker.WriteString(textContent(child))
+				return true
+			}
+			if isConditionalComment(child) {
+				for sibling := child.NextSibling; sibling != nil && !isEndifComment(sibling); sibling = sibling.NextSibling {
+					marker.WriteString(textContent(sibling))
+				}
+				return true
+			}
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	walk(node)
+	return strings.TrimFunc(marker.String(), unicode.IsSpace)
+}
+
+// newWordList creates the list for the marker of its first paragraph, an
+// ordered list with its numbering type and start for numbers and letters.
+func newWordList(marker string) *html.Node {
+	match := orderedMarkerRegex.FindStringSubmatch(marker)
+	if match == nil {
+		return &html.Node{Type: html.ElementNode, DataAtom: atom.Ul, Data: "ul"}
+	}
+	list := &html.Node{Type: html.ElementNode, DataAtom: atom.Ol, Data: "ol"}
+	number := match[1]
+	start := 1
+	switch {
+	case number[0] >= '0' && number[0] <= '9':
+		start, _ = strconv.Atoi(number)
+	case romanNumeralRegex.MatchString(number) && (len(number) > 1 || strings.EqualFold(number, "i")):
+		list.Attr = append(list.Attr, html.Attribute{Key: "type", Val: caseOf("i", number)})
+		start = romanValue(number)
+	default:
+		list.Attr = append(list.Attr, html.Attribute{Key: "type", Val: caseOf("a", number)})
+		start = int(unicode.ToLower(rune(number[0])) - 'a' + 1)
+	}
+	if start != 1 {
+		list.Attr = append(list.Attr, html.Attribute{Key: "start", Val: strconv.Itoa(start)})
+	}
+	return list
+}
+
+// caseOf returns s in upper case when the marker is.
+func caseOf(s, marker string) string {
+	if unicode.IsUpper(rune(marker[0])) {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+// romanValue returns the value of a roman numeral.
+func romanValue(numeral string) int {
+	values := map[byte]int{'i': 1, 'v': 5, 'x': 10, 'l': 50, 'c': 100, 'd': 500, 'm': 1000}
+	numeral = strings.ToLower(numeral)
+	value := 0
+	for i := range len(numeral) {
+		if i+1 < len(numeral) && values[numeral[i]] < values[numeral[i+1]] {
+			value -= values[numeral[i]]
+		} else {
+			value += values[numeral[i]]
+		}
+	}
+	return value
+}
+
+// rebuildWordLists replaces the runs of Word list paragraphs by nested lists.
+// Each paragraph becomes an item of the list of its level, and a new list
+// starts when the list of a level changes.
+func rebuildWordLists(node *html.Node) {
+	type openList struct {
+		list, level int
+		node, item  *html.Node
+	}
+
+	child := node.FirstChild
+	for child != nil {
+		if _, _, ok := wordListLevel(child); !ok {
+			rebuildWordLists(child)
+			child = child.NextSibling
+			continue
+		}
+
+		var open []openList
+		for child != nil {
+			if child.Type == html.TextNode && strings.TrimSpace(child.Data) == "" {
+				child = child.NextSibling
+				continue
+			}
+			list, level, ok := wordListLevel(child)
+			if !ok {
+				break
+			}
+			for len(open) > 0 {
+				top := open[len(open)-1]
+				if top.level < level || top.level == level && top.list == list {
+					break
+				}
+				open = open[:len(open)-1]
+			}
+			if len(open) == 0 || open[len(open)-1].level < level {
+				entry := openList{list: list, level: level, node: newWordList(wordListMarker(child))}
+				if len(open) == 0 {
+					node.InsertBefore(entry.node, child)
+				} else {
+					open[len(open)-1].item.AppendChild(entry.node)
+				}
+				open = append(open, entry)
+			}
+
+			item := &html.Node{Type: html.ElementNode, DataAtom: atom.Li, Data: "li"}
+			for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+				child.RemoveChild(grandchild)
+				item.AppendChild(grandchild)
+			}
+			open[len(open)-1].node.AppendChild(item)
+			open[len(open)-1].item = item
+
+			next := child.NextSibling
+			node.RemoveChild(child)
+			child = next
+		}
+	}
+}
+
+// isBlankParagraph reports whether the paragraph only holds spaces, which
+// Word writes as `<p class=MsoNormal><o:p>&nbsp;</o:p></p>` to space out
+// the document.
+func isBlankParagraph(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.DataAtom != atom.P {
+		return false
+	}
+	blank := true
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil && blank; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				blank = strings.TrimFunc(child.Data, unicode.IsSpace) == ""
+			case html.ElementNode:
+				if child.DataAtom != atom.Span && child.DataAtom != atom.Font && child.DataAtom != atom.Br && !strings.Contains(child.Data, ":") {
+					blank = false
+				}
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return blank
+}
+
+// removeWordNoise removes the Office elements, the markers of the list
+// paragraphs, the other content written for browsers without a feature, and
+// the blank paragraphs. The spaces of `mso-spacerun` and `mso-tab-count`
+// spans become a single space.
+func removeWordNoise(node *html.Node) {
+	child := node.FirstChild
+	for child != nil {
+		next := child.NextSibling
+		switch {
+		case isConditionalComment(child):
+			for next != nil && !isEndifComment(next) {
+				sibling := next
+				next = next.NextSibling
+				node.RemoveChild(sibling)
+			}
+			node.RemoveChild(child)
+		case child.Type != html.ElementNode:
+		case strings.Contains(child.Data, ":"):
+			if !itemInSlice(child.Data[:strings.Index(child.Data, ":")+1], officeElementPrefixes) {
+				// keep the content of the element in its place
+				removeWordNoise(child)
+				for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+					child.RemoveChild(grandchild)
+					node.InsertBefore(grandchild, child)
+				}
+			}
+			node.RemoveChild(child)
+		case strings.EqualFold(styleProperty(child, "mso-list"), "Ignore"), isBlankParagraph(child):
+			node.RemoveChild(child)
+		case styleProperty(child, "mso-spacerun") != "", styleProperty(child, "mso-tab-count") != "":
+			node.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, child)
+			node.RemoveChild(child)
+		default:
+			removeWordNoise(child)
+		}
+		child = next
+	}
+}
@@ -0,0 +1,65 @@
+package html2md
+
+import "testing"
+
+func TestWordPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Office elements and spacing",
+			input: `<p class=MsoNormal>Hello <st1:City w:st="on">Paris</st1:City>,<span style='mso-spacerun:yes'>&nbsp; </span>welcome<o:p></o:p></p>` +
+				`<p class=MsoNormal><o:p>&nbsp;</o:p></p>` +
+				`<p class=MsoNormal>Bye<span style='mso-tab-count:1'>&nbsp;&nbsp;&nbsp; </span>now</p>`,
+			expected: "Hello Paris, welcome\n\nBye now\n\n",
+		},
+		{
+			name: "Bulleted lists",
+			input: `<p class=MsoListParagraphCxSpFirst style='text-indent:-.25in;mso-list:l0 level1 lfo1'><![if !supportLists]><span style='font-family:Symbol'><span style='mso-list:Ignore'>·<span style='font:7.0pt "Times New Roman"'>&nbsp;&nbsp;&nbsp; </span></span></span><![endif]>First <b>item</b><o:p></o:p></p>` +
+				`<p class=MsoListParagraphCxSpMiddle style='margin-left:1.0in;mso-list:l0 level2 lfo1'><![if !supportLists]><span style='font-family:"Courier New"'><span style='mso-list:Ignore'>o<span style='font:7.0pt "Times New Roman"'>&nbsp;&nbsp; </span></span></span><![endif]>Nested<o:p></o:p></p>` +
+				`<p class=MsoListParagraphCxSpMiddle style='margin-left:1.5in;mso-list:l0 level3 lfo1'><![if !supportLists]><span style='font-family:Wingdings'><span style='mso-list:Ignore'>§<span>&nbsp;</span></span></span><![endif]>Deeper<o:p></o:p></p>` +
+				`<p class=MsoListParagraphCxSpLast style='text-indent:-.25in;mso-list:l0 level1 lfo1'><![if !supportLists]><span style='font-family:Symbol'><span style='mso-list:Ignore'>·<span>&nbsp;</span></span></span><![endif]>Second<o:p></o:p></p>` +
+				`<p class=MsoNormal>After</p>`,
+			expected: "- First **item**\n\t- Nested\n\t\t- Deeper\n\n- Second\n\nAfter\n\n",
+		},
+		{
+			name: "Numbered lists",
+			input: `<p class=MsoListParagraphCxSpFirst style='mso-list:l1 level1 lfo2'><!--[if !supportLists]--><span><span style='mso-list:Ignore'>3.<span>&nbsp;&nbsp; </span></span></span><!--[endif]-->Third</p>` +
+				`<p class=MsoListParagraphCxSpMiddle style='mso-list:l1 level2 lfo2'><!--[if !supportLists]--><span><span style='mso-list:Ignore'>b)<span>&nbsp;</span></span></span><!--[endif]-->Lettered</p>` +
+				`<p class=MsoListParagraphCxSpMiddle style='mso-list:l1 level2 lfo2'><!--[if !supportLists]--><span><span style='mso-list:Ignore'>c)<span>&nbsp;</span></span></span><!--[endif]-->More</p>` +
+				`<p class=MsoListParagraphCxSpLast style='mso-list:l1 level3 lfo2'><!--[if !supportLists]--><span><span style='mso-list:Ignore'>iv.<span>&nbsp;</span></span></span><!--[endif]-->Roman</p>`,
+			expected: "3. Third\n\tb. Lettered\n\tc. More\n\t\tiv. Roman\n\n",
+		},
+		{
+			name: "Another list",
+			input: `<p style='mso-list:l0 level1 lfo1'><span style='mso-list:Ignore'>1.&nbsp;</span>One</p>` +
+				`<p style='mso-list:l2 level1 lfo3'><span style='mso-list:Ignore'>·&nbsp;</span>Bullet</p>`,
+			expected: "1. One\n\n- Bullet\n\n",
+		},
+		{
+			name: "Fallbacks for browsers",
+			input: `<p class=MsoNormal>Line<br style='mso-special-character:line-break'><![if !supportLineBreakNewLine]><br style='mso-special-character:line-break'><![endif]>break ` +
+				`<!--[if gte vml 1]><v:shape><v:imagedata src="x.png"/></v:shape><![endif]--><![if !vml]><img src="image001.png" alt="pic"><![endif]></p>`,
+			expected: "Line  \nbreak ![pic](image001.png)\n\n",
+		},
+		{
+			name:     "Outlook lists",
+			input:    `<ul style='margin-top:0in' type=disc><li class=MsoListParagraph style='margin-left:0in;mso-list:l0 level1 lfo1'>Item<o:p></o:p></li></ul>`,
+			expected: "- Item\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := NewConverter(WithPreset(PresetWord)).ConvertString(test.input)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if output != test.expected {
+				t.Errorf("unexpected output:\nGot:      %s\nExpected: %s", replaceNewline(output), replaceNewline(test.expected))
+			}
+		})
+	}
+}
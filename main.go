@@ -2,9 +2,11 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/shravanasati/ananke/html2md"
@@ -13,10 +15,62 @@ import (
 const helpText = `
 ananke is a simple command line tool to convert html to markdown. it can read input from stdin as well as from the given arguments.
 
+convert an e-book to a markdown file per chapter, or to a single file with -single:
+    ananke book.epub -o outdir/ [-single]
+
 visit "https://github.com/shravanasati/ananke" for more information.
 `
 
+// isEPUB reports whether the argument is the path of an EPUB book.
+func isEPUB(arg string) bool {
+	return strings.EqualFold(filepath.Ext(arg), ".epub")
+}
+
+// epubArgs are the arguments of the conversion of a book.
+type epubArgs struct {
+	input  string
+	outdir string
+	single bool
+}
+
+// parseEPUBArgs parses the arguments of the conversion of a book: the path of
+// the book, which may be followed or preceded by the flags. It reports false
+// when the first argument after the flags isn't the path of an EPUB book, the
+// arguments are then the HTML to convert.
+func parseEPUBArgs(args []string) (epubArgs, bool, error) {
+	var parsed epubArgs
+	flags := flag.NewFlagSet("ananke", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&parsed.outdir, "o", "", "directory of the markdown files, named after the book by default")
+	flags.BoolVar(&parsed.single, "single", false, "write the book to a single markdown file")
+	if err := flags.Parse(args); err != nil || !isEPUB(flags.Arg(0)) {
+		return parsed, false, nil
+	}
+	parsed.input = flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return parsed, true, err
+	}
+	if flags.NArg() > 0 {
+		return parsed, true, fmt.Errorf("unexpected arguments after the book: %s", strings.Join(flags.Args(), " "))
+	}
+	if parsed.outdir == "" {
+		parsed.outdir = strings.TrimSuffix(filepath.Base(parsed.input), filepath.Ext(parsed.input))
+	}
+	return parsed, true, nil
+}
+
 func main() {
+	if book, ok, err := parseEPUBArgs(os.Args[1:]); ok {
+		if err == nil {
+			err = convertEPUB(book.input, book.outdir, book.single)
+		}
+		if err != nil {
+			fmt.Println("error: ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	converter := html2md.NewConverter()
 
 	// Check if there is any input available in stdin